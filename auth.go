@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func applyApiKeyAuth(name, in, keyName string, auth AuthConfig, urls urlList, conf *SiegeConfig) error {
+	key, exists := auth[name]["apikey"]
+	if !exists {
+		return fmt.Errorf("API Key not configured for %s scheme\n\tNeed `auth.%s.apikey`\n", name, name)
+	}
+
+	switch in {
+	case "query":
+		if conf.UsesVariables() {
+			// Encoding the query would escape the variable reference, so add it to the raw query instead
+			value := conf.Secret(name, "apikey", url.QueryEscape(key))
+
+			for idx := range urls {
+				if urls[idx].URL.RawQuery != "" {
+					urls[idx].URL.RawQuery += "&"
+				}
+				urls[idx].URL.RawQuery += fmt.Sprintf("%s=%s", url.QueryEscape(keyName), value)
+			}
+
+			break
+		}
+
+		for idx := range urls {
+			query := urls[idx].URL.Query()
+			query.Add(keyName, key)
+			urls[idx].URL.RawQuery = query.Encode()
+		}
+	case "header":
+		conf.Headers.Add(keyName, conf.Secret(name, "apikey", key))
+	case "cookie":
+		if conf.UsesVariables() {
+			fmt.Printf("Siege doesn't substitute variables in its cookies file, so the %s API key is written there as-is\n", name)
+		}
+
+		for idx := range urls {
+			cookie := http.Cookie{
+				Name:  keyName,
+				Value: key,
+			}
+			urls[idx].Cookies = append(urls[idx].Cookies, &cookie)
+		}
+	default:
+		return fmt.Errorf("Unrecognized API Key location `%s` used in %s\n\tExpected one of `query`, `header`, or `cookie`\n", in, name)
+	}
+
+	return nil
+}
+
+func applyLoginAuth(name string, auth AuthConfig, conf *SiegeConfig) error {
+	creds, exists := auth[name]["creds"]
+	if !exists {
+		return fmt.Errorf("Credentials not configured for %s scheme\n\tNeed `auth.%s.creds`\n", name, name)
+	}
+
+	loginCreds := strings.SplitN(creds, ":", 3)
+	if len(loginCreds) < 2 {
+		return fmt.Errorf("Credentials incorrect for %s scheme\n\tNeed `auth.%s.creds` to be `{user}:{pass}` or `{user}:{pass}:{realm}\n", name, name)
+	}
+
+	conf.LoginInfo.User = conf.Secret(name, "user", loginCreds[0])
+	conf.LoginInfo.Password = conf.Secret(name, "password", loginCreds[1])
+	if len(loginCreds) > 2 {
+		conf.LoginInfo.Realm = loginCreds[2]
+	}
+
+	return nil
+}
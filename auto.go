@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v2 "github.com/pb33f/libopenapi/datamodel/high/v2"
+	"github.com/pb33f/libopenapi/datamodel/low"
+	lowbase "github.com/pb33f/libopenapi/datamodel/low/base"
+	"github.com/pb33f/libopenapi/utils"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// operationReport keeps track of the operations `--auto` mode had to skip. Without `--auto`, any operation which
+// can't be converted stops the whole conversion instead.
+type operationReport struct {
+	auto    bool
+	skipped []string
+}
+
+func newOperationReport(c *cli.Context) *operationReport {
+	return &operationReport{auto: c.Bool("auto")}
+}
+
+// keep takes an operation's conversion results, returning the URLs it produced. In `--auto` mode, an operation which
+// failed is reported and skipped, keeping the URLs from before it.
+func (r *operationReport) keep(method, rawPath string, before urlList) func(urlList, error) (urlList, error) {
+	return func(urls urlList, err error) (urlList, error) {
+		if err == nil {
+			return urls, nil
+		}
+
+		if !r.auto {
+			return nil, err
+		}
+
+		operation := fmt.Sprintf("%s %s", strings.ToUpper(method), rawPath)
+		r.skipped = append(r.skipped, operation)
+
+		fmt.Printf("Couldn't convert %s automatically; skipping it\n\t%s\n", operation, strings.ReplaceAll(strings.TrimSpace(err.Error()), "\n", "\n\t"))
+
+		return before, nil
+	}
+}
+
+// printSummary lists the skipped operations, so they can be configured by hand
+func (r *operationReport) printSummary() {
+	if len(r.skipped) < 1 {
+		return
+	}
+
+	fmt.Printf("\nSkipped %d operation(s) which need configuring before they can be converted:\n\t%s\n", len(r.skipped), strings.Join(r.skipped, "\n\t"))
+}
+
+// fakeV2ParamValue generates a value for a v2 parameter, which (unlike a body parameter) describes its type and
+// constraints itself, rather than with a schema
+func fakeV2ParamValue(gen *payloadGenerator, param *v2.Parameter) (interface{}, error) {
+	if param.Type == "array" {
+		return fakeV2ItemsValue(gen, param.Items)
+	}
+
+	var node *yaml.Node
+	if lowParam := param.GoLow(); lowParam != nil {
+		node = gen.findParentNode(lowParam.Name.KeyNode)
+	}
+
+	schema := newV2Schema(node)
+
+	schema.Type = []string{param.Type}
+	schema.Format = param.Format
+	schema.Pattern = param.Pattern
+
+	if param.MinLength != nil {
+		minLength := int64(*param.MinLength)
+		schema.MinLength = &minLength
+	}
+
+	if param.MaxLength != nil {
+		maxLength := int64(*param.MaxLength)
+		schema.MaxLength = &maxLength
+	}
+
+	return gen.createFakePayloadFromSchema(schema)
+}
+
+// fakeV2ItemsValue generates a single-item array for a v2 array parameter
+func fakeV2ItemsValue(gen *payloadGenerator, items *v2.Items) (interface{}, error) {
+	if items == nil {
+		return []interface{}{}, nil
+	}
+
+	if value, exists := gen.pickFromEnum(items.Enum); exists {
+		return []interface{}{value}, nil
+	}
+
+	if items.Default != nil {
+		return []interface{}{items.Default}, nil
+	}
+
+	if items.Type == "array" {
+		item, err := fakeV2ItemsValue(gen, items.Items)
+
+		return []interface{}{item}, err
+	}
+
+	var node *yaml.Node
+	if lowItems := items.GoLow(); lowItems != nil {
+		node = gen.findParentNode(lowItems.Type.KeyNode)
+	}
+
+	schema := newV2Schema(node)
+
+	schema.Type = []string{items.Type}
+	schema.Format = items.Format
+	schema.Pattern = items.Pattern
+
+	if items.MinLength > 0 {
+		minLength := int64(items.MinLength)
+		schema.MinLength = &minLength
+	}
+
+	if items.MaxLength > 0 {
+		maxLength := int64(items.MaxLength)
+		schema.MaxLength = &maxLength
+	}
+
+	item, err := gen.createFakePayloadFromSchema(schema)
+
+	return []interface{}{item}, err
+}
+
+// newV2Schema builds a schema carrying the numeric limits of a v2 parameter (or its items), given the parameter's node
+// in the spec. libopenapi's v2 model truncates these to integers, and drops fractional ones entirely, so they're
+// copied across as nodes; the number generator reads them from there, like it does for any other schema.
+func newV2Schema(node *yaml.Node) *base.Schema {
+	lowSchema := &lowbase.Schema{}
+
+	if node != nil {
+		lowSchema.Minimum = findSchemaNumber(node, "minimum")
+		lowSchema.Maximum = findSchemaNumber(node, "maximum")
+		lowSchema.ExclusiveMinimum = findSchemaExclusive(node, "exclusiveMinimum")
+		lowSchema.ExclusiveMaximum = findSchemaExclusive(node, "exclusiveMaximum")
+		lowSchema.MultipleOf = findSchemaNumber(node, "multipleOf")
+	}
+
+	return base.NewSchema(lowSchema)
+}
+
+func findSchemaNumber(node *yaml.Node, key string) low.NodeReference[int64] {
+	keyNode, valueNode := utils.FindKeyNodeTop(key, node.Content)
+
+	return low.NodeReference[int64]{KeyNode: keyNode, ValueNode: valueNode}
+}
+
+func findSchemaExclusive(node *yaml.Node, key string) low.NodeReference[*lowbase.SchemaDynamicValue[bool, int64]] {
+	keyNode, valueNode := utils.FindKeyNodeTop(key, node.Content)
+	if valueNode == nil {
+		return low.NodeReference[*lowbase.SchemaDynamicValue[bool, int64]]{}
+	}
+
+	exclusive := new(lowbase.SchemaDynamicValue[bool, int64])
+	_ = valueNode.Decode(&exclusive.A)
+
+	return low.NodeReference[*lowbase.SchemaDynamicValue[bool, int64]]{Value: exclusive, KeyNode: keyNode, ValueNode: valueNode}
+}
+
+// findParentNode finds the mapping in the spec holding the given key node
+func (g *payloadGenerator) findParentNode(keyNode *yaml.Node) *yaml.Node {
+	if g.index == nil || keyNode == nil {
+		return nil
+	}
+
+	var search func(node *yaml.Node) *yaml.Node
+	search = func(node *yaml.Node) *yaml.Node {
+		for idx, child := range node.Content {
+			if child == keyNode && node.Kind == yaml.MappingNode && idx%2 == 0 {
+				return node
+			}
+
+			if found := search(child); found != nil {
+				return found
+			}
+		}
+
+		return nil
+	}
+
+	return search(g.index.GetRootNode())
+}
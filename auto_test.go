@@ -0,0 +1,122 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	v2 "github.com/pb33f/libopenapi/datamodel/high/v2"
+	"github.com/pb33f/libopenapi/index"
+)
+
+const v2BoundsSpec = `swagger: "2.0"
+info:
+  title: Bounds
+  version: "1"
+paths:
+  /things:
+    get:
+      parameters:
+        - name: page
+          in: query
+          type: integer
+          minimum: 10
+          maximum: 13
+          exclusiveMinimum: true
+        - name: size
+          in: query
+          type: integer
+          minimum: 0
+          maximum: 100
+          multipleOf: 25
+        - name: ratio
+          in: query
+          type: number
+          minimum: 0.5
+          maximum: 1
+          exclusiveMaximum: true
+        - name: ids
+          in: query
+          type: array
+          items:
+            type: integer
+            minimum: 3
+            maximum: 4
+      responses:
+        "200":
+          description: OK
+`
+
+// getV2BoundsParams parses the bounds spec, returning its parameters by name, and its index
+func getV2BoundsParams(t *testing.T) (map[string]*v2.Parameter, *index.SpecIndex) {
+	t.Helper()
+
+	document, err := libopenapi.NewDocument([]byte(v2BoundsSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model, errs := document.BuildV2Model()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	params := make(map[string]*v2.Parameter)
+	for _, param := range model.Model.Paths.PathItems["/things"].Get.Parameters {
+		params[param.Name] = param
+	}
+
+	return params, model.Index
+}
+
+func TestFakeV2ParamValueBounds(t *testing.T) {
+	params, idx := getV2BoundsParams(t)
+	gen := &payloadGenerator{enums: enumsFirst, maxDepth: 10, index: idx}
+
+	tests := []struct {
+		param string
+		check func(interface{}) bool
+	}{
+		{
+			param: "page",
+			check: func(value interface{}) bool { number, ok := value.(int); return ok && number > 10 && number <= 13 },
+		},
+		{
+			param: "size",
+			check: func(value interface{}) bool {
+				number, ok := value.(int)
+				return ok && number >= 0 && number <= 100 && number%25 == 0
+			},
+		},
+		{
+			param: "ratio",
+			check: func(value interface{}) bool { number, ok := value.(float64); return ok && number >= 0.5 && number < 1 },
+		},
+		{
+			param: "ids",
+			check: func(value interface{}) bool {
+				items, ok := value.([]interface{})
+				if !ok || len(items) != 1 {
+					return false
+				}
+
+				number, ok := items[0].(int)
+				return ok && number >= 3 && number <= 4
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.param, func(t *testing.T) {
+			for variant := 0; variant < 10; variant++ {
+				value, err := fakeV2ParamValue(gen.withVariant(variant), params[test.param])
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !test.check(value) {
+					t.Errorf("variant %d: %#v is out of bounds", variant, value)
+				}
+			}
+		})
+	}
+}
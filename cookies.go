@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The date format Siege uses for cookie expiry times
+const siegeCookieTimeFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// siegeCookie is a cookie along with the attributes Siege needs to decide which requests it belongs to
+type siegeCookie struct {
+	Name    string
+	Value   string
+	Domain  string
+	Path    string
+	Expires time.Time
+	Secure  bool
+}
+
+// String formats the cookie the way Siege writes it into its own cookies file: the ID of the thread which received it
+// (always 0 here, since no thread has yet), and the cookie, in the same form as a Set-Cookie header
+func (c siegeCookie) String() string {
+	parts := []string{
+		fmt.Sprintf("%s=%s", c.Name, c.Value),
+		fmt.Sprintf("domain=%s", c.Domain),
+		fmt.Sprintf("path=%s", c.Path),
+		fmt.Sprintf("expires=%s", c.Expires.UTC().Format(siegeCookieTimeFormat)),
+	}
+
+	if c.Secure {
+		parts = append(parts, "secure")
+	}
+
+	return fmt.Sprintf("0 | %s", strings.Join(parts, "; "))
+}
+
+// SiegeCookies collects the cookies for every URL in the list. Each one is scoped to the host of its URL, and to the
+// cookie's own path, or the whole site when it doesn't have one. Siege only loads cookies that haven't expired, so
+// session cookies are given the lifetime passed in. Cookies set for the same host, path, and name are only kept once.
+func (l urlList) SiegeCookies(lifetime time.Duration) []siegeCookie {
+	cookies := make(map[string]siegeCookie)
+	expires := time.Now().Add(lifetime)
+
+	for _, data := range l {
+		for _, cookie := range data.Cookies {
+			converted := newSiegeCookie(cookie, data, expires)
+			cookies[fmt.Sprintf("%s|%s|%s", converted.Domain, converted.Path, converted.Name)] = converted
+		}
+	}
+
+	keys := make([]string, 0, len(cookies))
+	for key := range cookies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := make([]siegeCookie, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, cookies[key])
+	}
+
+	return list
+}
+
+func newSiegeCookie(cookie *http.Cookie, data urlData, expires time.Time) siegeCookie {
+	converted := siegeCookie{
+		Name:    cookie.Name,
+		Value:   cookie.Value,
+		Domain:  cookie.Domain,
+		Path:    cookie.Path,
+		Expires: cookie.Expires,
+		Secure:  cookie.Secure || data.URL.Scheme == "https",
+	}
+
+	if converted.Domain == "" {
+		converted.Domain = data.URL.Hostname()
+	}
+
+	if converted.Path == "" {
+		converted.Path = "/"
+	}
+
+	if converted.Expires.IsZero() {
+		converted.Expires = expires
+	}
+
+	return converted
+}
+
+// siegeCookieFile renders cookies into the contents of a Siege cookies file
+func siegeCookieFile(cookies []siegeCookie) string {
+	writer := new(strings.Builder)
+
+	writer.WriteString("# Siege cookies file, generated by openapi2siege\n")
+	writer.WriteString("# Each line holds a thread ID and a cookie, in the same form as a Set-Cookie header\n")
+
+	for _, cookie := range cookies {
+		writer.WriteString(cookie.String() + "\n")
+	}
+
+	return writer.String()
+}
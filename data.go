@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// Ways to choose which rows of a data file to use, when it has more than the limit
+const (
+	sampleFirst  = "first"
+	sampleEven   = "even"
+	sampleRandom = "random"
+)
+
+// dataSet is the sampled rows of a CSV or JSON lines data file
+type dataSet struct {
+	Columns []string
+	Rows    []map[string]interface{}
+}
+
+// dataValue picks the value for a data-driven parameter or payload field, from the row matching the current variant.
+// Values drawn from the same file (and sampling) come from the same row, so related columns stay together, while
+// separate files are paired up row by row.
+func (g *payloadGenerator) dataValue(config ParamConfig) (interface{}, error) {
+	set, err := g.loadDataSet(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(set.Rows) < 1 {
+		return nil, fmt.Errorf("%s has no rows", config.From)
+	}
+
+	g.expand(len(set.Rows))
+	row := set.Rows[g.variant%len(set.Rows)]
+
+	column := config.Column
+	if column == "" && len(set.Columns) > 0 {
+		column = set.Columns[0]
+	}
+
+	value, exists := row[column]
+	if !exists {
+		return nil, fmt.Errorf("%s has no `%s` column", config.From, column)
+	}
+
+	return value, nil
+}
+
+// loadDataSet reads and samples a data file, once per file and sampling
+func (g *payloadGenerator) loadDataSet(config ParamConfig) (*dataSet, error) {
+	limit := g.dataLimit
+	if config.Limit != nil {
+		limit = *config.Limit
+	}
+
+	sample := config.Sample
+	if sample == "" {
+		sample = g.dataSample
+	}
+
+	if !slices.Contains([]string{sampleFirst, sampleEven, sampleRandom}, sample) {
+		return nil, fmt.Errorf("unknown sampling `%s`; it should be `%s`, `%s`, or `%s`", sample, sampleFirst, sampleEven, sampleRandom)
+	}
+
+	key := fmt.Sprintf("%s|%d|%s", config.From, limit, sample)
+	if set, exists := g.dataSets[key]; exists {
+		return set, nil
+	}
+
+	raw, err := os.ReadFile(config.From)
+	if err != nil {
+		return nil, err
+	}
+
+	var set *dataSet
+
+	switch strings.ToLower(filepath.Ext(config.From)) {
+	case ".csv":
+		set, err = parseCSVData(raw)
+	case ".jsonl", ".ndjson":
+		set, err = parseJSONLinesData(raw)
+	default:
+		err = fmt.Errorf("%s isn't a CSV (.csv) or JSON lines (.jsonl, .ndjson) file", config.From)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	set.Rows = g.sampleRows(set.Rows, limit, sample)
+	g.dataSets[key] = set
+
+	return set, nil
+}
+
+// sampleRows cuts rows down to the limit (if it's positive), taking the first rows, rows spread evenly through the
+// file, or a random selection, which keeps the file's order
+func (g *payloadGenerator) sampleRows(rows []map[string]interface{}, limit int, sample string) []map[string]interface{} {
+	if limit <= 0 || len(rows) <= limit {
+		return rows
+	}
+
+	switch sample {
+	case sampleEven:
+		sampled := make([]map[string]interface{}, 0, limit)
+		for idx := 0; idx < limit; idx++ {
+			sampled = append(sampled, rows[idx*len(rows)/limit])
+		}
+
+		return sampled
+	case sampleRandom:
+		picked := g.random.Perm(len(rows))[:limit]
+		slices.Sort(picked)
+
+		sampled := make([]map[string]interface{}, 0, limit)
+		for _, idx := range picked {
+			sampled = append(sampled, rows[idx])
+		}
+
+		return sampled
+	default:
+		return rows[:limit]
+	}
+}
+
+// parseCSVData reads a CSV file with a header row; every value is a string
+func parseCSVData(raw []byte) (*dataSet, error) {
+	records, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) < 1 {
+		return nil, fmt.Errorf("there's no header row")
+	}
+
+	set := &dataSet{Columns: records[0], Rows: make([]map[string]interface{}, 0, len(records)-1)}
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(record))
+		for idx, value := range record {
+			if idx < len(set.Columns) {
+				row[set.Columns[idx]] = value
+			}
+		}
+
+		set.Rows = append(set.Rows, row)
+	}
+
+	return set, nil
+}
+
+// parseJSONLinesData reads a file with one JSON value per line. Objects are split into columns by key; any other
+// value is available as the unnamed column.
+func parseJSONLinesData(raw []byte) (*dataSet, error) {
+	set := &dataSet{Rows: make([]map[string]interface{}, 0)}
+
+	reader := bufio.NewReader(bytes.NewReader(raw))
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var value interface{}
+			if jsonErr := json.Unmarshal(line, &value); jsonErr != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, jsonErr)
+			}
+
+			row, isObject := value.(map[string]interface{})
+			if !isObject {
+				row = map[string]interface{}{"": value}
+			}
+
+			set.Rows = append(set.Rows, row)
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(set.Rows) > 0 {
+		for column := range set.Rows[0] {
+			set.Columns = append(set.Columns, column)
+		}
+		slices.Sort(set.Columns)
+	}
+
+	return set, nil
+}
+
+// applyDataFields replaces fields of a payload with values from data files, following dotted paths into nested
+// objects. The payload is copied along each path, since examples are shared between variants. Strings (such as
+// everything read from a CSV file) are converted to the type the schema gives the field, where it gives one.
+func (g *payloadGenerator) applyDataFields(payload interface{}, schemaProxy *base.SchemaProxy, fields map[string]ParamConfig) (interface{}, error) {
+	names := maps.Keys(fields)
+	slices.Sort(names)
+
+	for _, name := range names {
+		path := strings.Split(name, ".")
+
+		value, err := g.paramConfigValue(fields[name])
+		if err != nil {
+			return nil, fmt.Errorf("`%s`: %v", name, err)
+		}
+
+		value, err = coerceDataValue(value, getFieldSchema(schemaProxy, path))
+		if err != nil {
+			return nil, fmt.Errorf("`%s`: %v", name, err)
+		}
+
+		payload, err = setPayloadField(payload, path, value)
+		if err != nil {
+			return nil, fmt.Errorf("`%s`: %v", name, err)
+		}
+	}
+
+	return payload, nil
+}
+
+// paramConfigValue resolves a configured value, reading it from its data file if it has one
+func (g *payloadGenerator) paramConfigValue(config ParamConfig) (interface{}, error) {
+	if config.From == "" {
+		return config.Value, nil
+	}
+
+	return g.dataValue(config)
+}
+
+// setPayloadField sets a field of a payload, creating any objects missing along the way. Anything else in the way is
+// an error, rather than being replaced.
+func setPayloadField(payload interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) < 1 {
+		return value, nil
+	}
+
+	object, isObject := payload.(map[string]interface{})
+	if !isObject && payload != nil {
+		return nil, fmt.Errorf("the payload has no object to put `%s` in", path[0])
+	}
+
+	copied := make(map[string]interface{}, len(object)+1)
+	for key, existing := range object {
+		copied[key] = existing
+	}
+
+	field, err := setPayloadField(copied[path[0]], path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+
+	copied[path[0]] = field
+
+	return copied, nil
+}
+
+// getFieldSchema follows a dotted path through a schema's properties, returning nil if the schema doesn't describe it
+func getFieldSchema(schemaProxy *base.SchemaProxy, path []string) *base.Schema {
+	if schemaProxy == nil {
+		return nil
+	}
+
+	schema, err := schemaProxy.BuildSchema()
+	if err != nil || schema == nil || len(path) < 1 {
+		return schema
+	}
+
+	return getFieldSchema(schema.Properties[path[0]], path[1:])
+}
+
+// coerceDataValue converts a string to the type its schema expects, leaving it alone if the schema allows strings (or
+// there's no schema to go on)
+func coerceDataValue(value interface{}, schema *base.Schema) (interface{}, error) {
+	text, isString := value.(string)
+	if !isString || schema == nil || len(schema.Type) < 1 || slices.Contains(schema.Type, "string") {
+		return value, nil
+	}
+
+	for _, schemaType := range schema.Type {
+		switch schemaType {
+		case "integer":
+			if number, err := strconv.ParseInt(text, 10, 64); err == nil {
+				return int(number), nil
+			}
+		case "number":
+			if number, err := strconv.ParseFloat(text, 64); err == nil {
+				return number, nil
+			}
+		case "boolean":
+			if boolean, err := strconv.ParseBool(text); err == nil {
+				return boolean, nil
+			}
+		case "null":
+			if text == "" || text == "null" {
+				return nil, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("`%s` isn't a valid %s", text, strings.Join(schema.Type, " or "))
+}
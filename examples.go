@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"golang.org/x/exp/maps"
+)
+
+// Ways to combine the named examples of parameters and request bodies
+const (
+	examplesFirst   = "first"
+	examplesZip     = "zip"
+	examplesProduct = "product"
+)
+
+// exampleValues lists the values of an object's named examples, ordered by name, or its single example if it has no
+// named ones. Examples which only have an external value are left out, since there's nothing to send.
+func exampleValues(example interface{}, examples map[string]*base.Example) []interface{} {
+	names := maps.Keys(examples)
+	sort.Strings(names)
+
+	values := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		if examples[name] == nil || examples[name].Value == nil {
+			continue
+		}
+
+		values = append(values, examples[name].Value)
+	}
+
+	if len(values) < 1 && example != nil {
+		values = append(values, example)
+	}
+
+	return values
+}
+
+// exampleIndex picks which of a set of examples the current variant uses, according to the `generate.examples`
+// setting. Zipped sets all move to their next example together, while the cross product moves each set only once
+// every earlier set (as counted by nextExampleSet) has been through all of its examples.
+func (g *payloadGenerator) exampleIndex(count int) int {
+	if count < 1 {
+		return 0
+	}
+
+	switch g.examples {
+	case examplesZip:
+		g.expand(count)
+
+		return g.variant % count
+	case examplesProduct:
+		g.expand(g.exampleStride * count)
+
+		return (g.variant / g.exampleStride) % count
+	default:
+		return 0
+	}
+}
+
+// nextExampleSet moves past a set of examples, so the cross product varies the next set independently of it
+func (g *payloadGenerator) nextExampleSet(count int) {
+	if count > 1 {
+		g.exampleStride *= count
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"golang.org/x/exp/maps"
+)
+
+// Which oneOf/anyOf branches generated values use
+const (
+	branchesFirst = "first"
+	branchesAll   = "all"
+)
+
+// createFakeBranch generates a value from one branch of a oneOf/anyOf schema, setting the schema's discriminator
+// property to match. Branches which can't be generated without recursing too far are skipped in favor of the next.
+func (g *payloadGenerator) createFakeBranch(schema *base.Schema, branches []*base.SchemaProxy) (interface{}, error) {
+	first := 0
+	if g.branches == branchesAll {
+		g.expand(len(branches))
+		first = g.variant % len(branches)
+	}
+
+	var err error
+
+	for attempt := 0; attempt < len(branches); attempt++ {
+		branch := branches[(first+attempt)%len(branches)]
+
+		var payload interface{}
+		payload, err = g.createFakePayload(branch)
+		if errors.Is(err, errRecursionLimit) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if schema.Discriminator != nil {
+			if object, isObject := payload.(map[string]interface{}); isObject {
+				tagDiscriminator(schema.Discriminator, branch, object)
+			}
+		}
+
+		return payload, nil
+	}
+
+	return nil, err
+}
+
+// tagDiscriminator sets the discriminator property of a generated object to the value identifying the given schema:
+// its key in the discriminator's mapping, or else its own name. Inline schemas can't be identified, so are left as-is.
+func tagDiscriminator(discriminator *base.Discriminator, schemaProxy *base.SchemaProxy, payload map[string]interface{}) {
+	if discriminator.PropertyName == "" || !schemaProxy.GoLow().IsSchemaReference() {
+		return
+	}
+
+	ref := schemaProxy.GoLow().GetSchemaReference()
+	name := ref[strings.LastIndex(ref, "/")+1:]
+
+	// Check the mapping in a stable order, in case more than one value maps to the same schema
+	keys := maps.Keys(discriminator.Mapping)
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if target := discriminator.Mapping[key]; target == ref || target == name {
+			payload[discriminator.PropertyName] = key
+			return
+		}
+	}
+
+	payload[discriminator.PropertyName] = name
+}
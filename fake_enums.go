@@ -0,0 +1,109 @@
+package main
+
+import (
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/datamodel/low"
+	"github.com/pb33f/libopenapi/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Ways to choose which enum member a generated value uses
+const (
+	enumsFirst      = "first"
+	enumsRandom     = "random"
+	enumsRoundRobin = "round-robin"
+)
+
+// pickEnumValue returns the schema's const, or one of its enum members, if it declares either
+func (g *payloadGenerator) pickEnumValue(schema *base.Schema) (interface{}, bool) {
+	node := g.schemaNode(schema)
+	if node != nil {
+		// libopenapi v0.6 doesn't support `const` at all, so read it from the spec directly
+		if _, constNode := utils.FindKeyNodeTop("const", node.Content); constNode != nil {
+			var value interface{}
+			if err := constNode.Decode(&value); err == nil {
+				return value, true
+			}
+		}
+	}
+
+	var members []interface{}
+	if lowSchema := schema.GoLow(); lowSchema != nil {
+		members = decodeEnum(lowSchema.Enum, schema.Enum)
+	} else {
+		members = schema.Enum
+	}
+
+	return g.pickFromEnum(members)
+}
+
+// pickFromEnum chooses a member of an enum according to the `generate.enums` setting. First selection always picks
+// the first member, except for the items of an array (or entries of a map), which take successive members so they
+// can still be distinct; round-robin selection picks the member matching the current variant.
+func (g *payloadGenerator) pickFromEnum(members []interface{}) (interface{}, bool) {
+	if len(members) < 1 {
+		return nil, false
+	}
+
+	switch g.enums {
+	case enumsRandom:
+		return members[g.random.Intn(len(members))], true
+	case enumsRoundRobin:
+		g.expand(len(members))
+
+		return members[g.variant%len(members)], true
+	default:
+		return members[g.item%len(members)], true
+	}
+}
+
+// decodeEnum reads enum members from the spec, keeping their types; libopenapi converts schema enums into strings
+func decodeEnum(ref low.NodeReference[[]low.ValueReference[any]], fallback []interface{}) []interface{} {
+	if ref.IsEmpty() {
+		return fallback
+	}
+
+	members := make([]interface{}, 0, len(ref.Value))
+	for _, member := range ref.Value {
+		if member.ValueNode == nil {
+			members = append(members, member.Value)
+			continue
+		}
+
+		var value interface{}
+		if err := member.ValueNode.Decode(&value); err != nil {
+			return fallback
+		}
+
+		members = append(members, value)
+	}
+
+	return members
+}
+
+// schemaNode finds the spec node a schema was built from, following any reference
+func (g *payloadGenerator) schemaNode(schema *base.Schema) *yaml.Node {
+	if schema.ParentProxy == nil {
+		return nil
+	}
+
+	node := schema.ParentProxy.GoLow().GetValueNode()
+	if node == nil {
+		return nil
+	}
+
+	if isRef, _, _ := utils.IsNodeRefValue(node); isRef {
+		if g.index == nil {
+			return nil
+		}
+
+		located, err := low.LocateRefNode(node, g.index)
+		if err != nil {
+			return nil
+		}
+
+		node = located
+	}
+
+	return node
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/datamodel/low"
+)
+
+// Upper limit on how far past the lower bound variants will go, when there's no upper bound
+const fakeNumberSpread = 100
+
+// numberBounds describes the values a numeric schema allows
+type numberBounds struct {
+	Min, Max                   float64
+	HasMin, HasMax             bool
+	ExclusiveMin, ExclusiveMax bool
+	Step                       float64
+	Decimals                   int
+	// Continuous values aren't restricted to multiples of the step
+	Continuous bool
+}
+
+// createFakeNumber generates a number which satisfies the schema's minimum, maximum, exclusive bounds, and multipleOf.
+// Unbounded values start one step above zero, since zero is rarely a useful page size or quantity. Each variant
+// moves another step away from the first value, wrapping around within the allowed range.
+func createFakeNumber(schema *base.Schema, integer bool, variant int) (float64, error) {
+	bounds := getNumberBounds(schema, integer)
+
+	first, last, err := bounds.gridRange()
+	if err != nil {
+		return 0, fmt.Errorf("Can't generate a number for %s; %v\n", describeSchema(schema), err)
+	}
+
+	switch {
+	case bounds.HasMin && bounds.HasMax:
+		steps := int((last-first)/bounds.Step) + 1
+		if steps < 1 {
+			steps = 1
+		}
+
+		return bounds.round(first + float64(variant%steps)*bounds.Step), nil
+	case bounds.HasMin:
+		return bounds.round(first + float64(variant%fakeNumberSpread)*bounds.Step), nil
+	case bounds.HasMax:
+		// Count down from the first positive value, if the maximum allows one
+		return bounds.round(math.Min(bounds.Step, last) - float64(variant%fakeNumberSpread)*bounds.Step), nil
+	default:
+		return bounds.round(bounds.Step + float64(variant%fakeNumberSpread)*bounds.Step), nil
+	}
+}
+
+func getNumberBounds(schema *base.Schema, integer bool) numberBounds {
+	bounds := numberBounds{Step: 1}
+
+	lowSchema := schema.GoLow()
+	if lowSchema == nil {
+		return bounds
+	}
+
+	// libopenapi truncates these keywords to integers, so read them from the spec directly
+	if step, decimals, exists := schemaNumber(lowSchema.MultipleOf); exists && step > 0 {
+		bounds.Step, bounds.Decimals = step, decimals
+	} else {
+		bounds.Continuous = !integer
+	}
+
+	if integer && bounds.Step < 1 {
+		// An integer multiple of a fraction is still an integer; just step by the smallest integer multiple
+		bounds.Step = math.Ceil(1/bounds.Step) * bounds.Step
+		bounds.Decimals = 0
+	}
+
+	var minDecimals, maxDecimals int
+	bounds.Min, minDecimals, bounds.HasMin = schemaNumber(lowSchema.Minimum)
+	bounds.Max, maxDecimals, bounds.HasMax = schemaNumber(lowSchema.Maximum)
+
+	if bounds.Continuous {
+		// Leave room for a value between the bounds
+		bounds.Decimals = 1 + int(math.Max(float64(minDecimals), float64(maxDecimals)))
+	}
+
+	if schema.ExclusiveMinimum != nil {
+		if schema.ExclusiveMinimum.IsA() {
+			bounds.ExclusiveMin = schema.ExclusiveMinimum.A && bounds.HasMin
+		} else if value, _, exists := schemaNumber(low.NodeReference[int64]{ValueNode: lowSchema.ExclusiveMinimum.ValueNode}); exists && (!bounds.HasMin || value >= bounds.Min) {
+			bounds.Min, bounds.HasMin, bounds.ExclusiveMin = value, true, true
+		}
+	}
+
+	if schema.ExclusiveMaximum != nil {
+		if schema.ExclusiveMaximum.IsA() {
+			bounds.ExclusiveMax = schema.ExclusiveMaximum.A && bounds.HasMax
+		} else if value, _, exists := schemaNumber(low.NodeReference[int64]{ValueNode: lowSchema.ExclusiveMaximum.ValueNode}); exists && (!bounds.HasMax || value <= bounds.Max) {
+			bounds.Max, bounds.HasMax, bounds.ExclusiveMax = value, true, true
+		}
+	}
+
+	return bounds
+}
+
+// gridRange finds the smallest and largest multiples of the step within the bounds
+func (b numberBounds) gridRange() (float64, float64, error) {
+	first, last := 0.0, 0.0
+
+	if b.HasMin {
+		first = math.Ceil(b.Min/b.Step) * b.Step
+		if b.ExclusiveMin && first <= b.Min {
+			first += b.Step
+		}
+	} else if b.HasMax {
+		first = math.Floor(b.Max/b.Step) * b.Step
+		if b.ExclusiveMax && first >= b.Max {
+			first -= b.Step
+		}
+	}
+
+	if b.HasMax {
+		last = math.Floor(b.Max/b.Step) * b.Step
+		if b.ExclusiveMax && last >= b.Max {
+			last -= b.Step
+		}
+
+		if last < first && b.Continuous && b.HasMin {
+			// There's no whole number in range, but any number will do
+			middle := (b.Min + b.Max) / 2
+
+			return middle, middle, nil
+		}
+
+		if last < first {
+			return 0, 0, fmt.Errorf("no multiple of %s lies between %s and %s", b.format(b.Step), b.format(b.Min), b.format(b.Max))
+		}
+	}
+
+	return first, last, nil
+}
+
+// round removes floating point noise, using the precision of the multipleOf value (or the bounds)
+func (b numberBounds) round(value float64) float64 {
+	scale := math.Pow(10, float64(b.Decimals))
+
+	return math.Round(value*scale) / scale
+}
+
+func (b numberBounds) format(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// schemaNumber parses a numeric keyword from its spec node, along with the number of decimal places it uses
+func schemaNumber(ref low.NodeReference[int64]) (float64, int, bool) {
+	if ref.ValueNode == nil {
+		return 0, 0, false
+	}
+
+	value, err := strconv.ParseFloat(ref.ValueNode.Value, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	decimals := 0
+	if _, fraction, found := strings.Cut(strings.ToLower(ref.ValueNode.Value), "."); found {
+		fraction, _, _ = strings.Cut(fraction, "e")
+		decimals = len(fraction)
+	}
+
+	return value, decimals, true
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+)
+
+// fakeStringFormat builds sample values for one of the string formats defined by OpenAPI and JSON Schema. Most formats
+// have a part which can be any run of letters and digits, starting as Filler and grown or cut to fit the schema's
+// length constraints; the rest are fixed for each variant, and leave Filler empty.
+type fakeStringFormat struct {
+	Filler string
+	Build  func(variant int, filler string) string
+}
+
+// Sample values for the string formats defined by OpenAPI and JSON Schema; each variant produces a different value
+var fakeStringFormats = map[string]fakeStringFormat{
+	"date": {Build: func(variant int, _ string) string {
+		return fakeStringEpoch.AddDate(0, 0, variant).Format("2006-01-02")
+	}},
+	"date-time": {Build: func(variant int, _ string) string {
+		return fakeStringEpoch.AddDate(0, 0, variant).Format(time.RFC3339)
+	}},
+	"time": {Build: func(variant int, _ string) string {
+		return fakeStringEpoch.Add(time.Duration(variant) * time.Minute).Format("15:04:05Z07:00")
+	}},
+	"duration": {Build: func(variant int, _ string) string {
+		return fmt.Sprintf("P%dD", variant+1)
+	}},
+	"email": {Filler: "user", Build: func(_ int, filler string) string {
+		return filler + "@example.com"
+	}},
+	"idn-email": {Filler: "user", Build: func(_ int, filler string) string {
+		return filler + "@example.com"
+	}},
+	"hostname": {Filler: "host", Build: func(_ int, filler string) string {
+		return filler + ".example.com"
+	}},
+	"idn-hostname": {Filler: "host", Build: func(_ int, filler string) string {
+		return filler + ".example.com"
+	}},
+	"ipv4": {Build: func(variant int, _ string) string {
+		// 192.0.2.0/24 is reserved for documentation
+		return fmt.Sprintf("192.0.2.%d", variant%254+1)
+	}},
+	"ipv6": {Build: func(variant int, _ string) string {
+		// 2001:db8::/32 is reserved for documentation
+		return fmt.Sprintf("2001:db8::%x", variant+1)
+	}},
+	"uri": {Filler: "page", Build: func(_ int, filler string) string {
+		return "https://example.com/" + filler
+	}},
+	"url": {Filler: "page", Build: func(_ int, filler string) string {
+		return "https://example.com/" + filler
+	}},
+	"iri": {Filler: "page", Build: func(_ int, filler string) string {
+		return "https://example.com/" + filler
+	}},
+	"uri-reference": {Filler: "example", Build: func(_ int, filler string) string {
+		return "/" + filler
+	}},
+	"iri-reference": {Filler: "example", Build: func(_ int, filler string) string {
+		return "/" + filler
+	}},
+	"uri-template": {Filler: "page", Build: func(_ int, filler string) string {
+		return "https://example.com/" + filler + "{id}"
+	}},
+	"uuid": {Build: func(variant int, _ string) string {
+		return fmt.Sprintf("3fa85f64-5717-4562-b3fc-%012x", 0x2c963f66afa6+variant)
+	}},
+	"json-pointer": {Filler: "example", Build: func(_ int, filler string) string {
+		return "/" + filler
+	}},
+	"relative-json-pointer": {Filler: "example", Build: func(variant int, filler string) string {
+		return fmt.Sprintf("%d/%s", variant, filler)
+	}},
+	"regex": {Filler: "a", Build: func(_ int, filler string) string {
+		return "^.*" + filler + "$"
+	}},
+	"password": {Filler: "Passw0rd!", Build: func(_ int, filler string) string {
+		return filler
+	}},
+}
+
+var fakeStringEpoch = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeStringSuffix distinguishes variants after the first, which gets no suffix at all
+func fakeStringSuffix(variant int) string {
+	if variant < 1 {
+		return ""
+	}
+
+	return strconv.Itoa(variant)
+}
+
+// createFakeString generates a string which satisfies the schema's pattern, format, and length constraints
+func createFakeString(schema *base.Schema, variant int) (string, error) {
+	minLength, maxLength := int64(0), int64(-1)
+	if schema.MinLength != nil {
+		minLength = *schema.MinLength
+	}
+	if schema.MaxLength != nil {
+		maxLength = *schema.MaxLength
+	}
+
+	if maxLength >= 0 && maxLength < minLength {
+		return "", fmt.Errorf("Can't generate a string for %s; maxLength %d is less than minLength %d\n", describeSchema(schema), maxLength, minLength)
+	}
+
+	if schema.Pattern != "" {
+		value, err := createFakeStringFromPattern(schema.Pattern, minLength, maxLength, variant)
+		if err == nil {
+			return value, nil
+		}
+
+		fmt.Printf("Couldn't generate a value matching the pattern `%s`; falling back to the schema's format and length\n\t%v\n", schema.Pattern, err)
+	}
+
+	if schema.Format == "byte" {
+		// Base64 encodes every 3 bytes as 4 characters
+		maxBytes := maxLength
+		if maxBytes >= 0 {
+			maxBytes = maxLength / 4 * 3
+		}
+
+		return base64.StdEncoding.EncodeToString([]byte(fitFakeString("test"+fakeStringSuffix(variant), (minLength+3)/4*3, maxBytes))), nil
+	}
+
+	if format, exists := fakeStringFormats[schema.Format]; exists {
+		value, fits := format.fit(variant, minLength, maxLength)
+		if !fits {
+			return "", fmt.Errorf("Can't generate a string for %s; no `%s` value fits between its minLength and maxLength\n", describeSchema(schema), schema.Format)
+		}
+
+		return value, nil
+	}
+
+	return fitFakeString("test"+fakeStringSuffix(variant), minLength, maxLength), nil
+}
+
+// fit builds a value for the variant between the given lengths (a negative maximum is unbounded), by growing or cutting
+// the format's filler, and reports whether it managed to
+func (f fakeStringFormat) fit(variant int, minLength, maxLength int64) (string, bool) {
+	if f.Filler == "" {
+		value := f.Build(variant, "")
+
+		return value, int64(len(value)) >= minLength && (maxLength < 0 || int64(len(value)) <= maxLength)
+	}
+
+	// The filler always keeps at least one character, so the value still has the format's shape
+	fixed := int64(len(f.Build(variant, "")))
+	fillerMin, fillerMax := minLength-fixed, maxLength-fixed
+	if fillerMin < 1 {
+		fillerMin = 1
+	}
+
+	if maxLength >= 0 && fillerMax < fillerMin {
+		return "", false
+	}
+
+	if maxLength < 0 {
+		fillerMax = -1
+	}
+
+	return f.Build(variant, fitFakeString(f.Filler+fakeStringSuffix(variant), fillerMin, fillerMax)), true
+}
+
+// fitFakeString repeats or truncates a value until it fits between the given lengths; a negative maximum is unbounded
+func fitFakeString(value string, minLength, maxLength int64) string {
+	if int64(len(value)) < minLength && len(value) > 0 {
+		value = strings.Repeat(value, int(minLength)/len(value)+1)[:minLength]
+	}
+
+	if maxLength >= 0 && int64(len(value)) > maxLength {
+		value = value[:maxLength]
+	}
+
+	return value
+}
+
+// createFakeStringFromPattern generates a string matching a regular expression, growing any open-ended repetitions
+// until it reaches the minimum length
+func createFakeStringFromPattern(pattern string, minLength, maxLength int64, variant int) (string, error) {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+
+	matcher, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+
+	for extra := 0; extra <= int(minLength)+1; extra++ {
+		value = generateFromRegexp(parsed, extra, variant)
+
+		if int64(len(value)) >= minLength {
+			break
+		}
+	}
+
+	if maxLength >= 0 && int64(len(value)) > maxLength {
+		return "", fmt.Errorf("the shortest match is longer than the maxLength of %d", maxLength)
+	}
+
+	if !matcher.MatchString(value) {
+		return "", fmt.Errorf("the generated value `%s` doesn't match", value)
+	}
+
+	return value, nil
+}
+
+// generateFromRegexp walks a parsed regular expression, repeating each repetition `extra` more times than its minimum
+// (within its maximum). The variant selects which alternative, and which character of each class, to use.
+func generateFromRegexp(re *syntax.Regexp, extra, variant int) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return string(re.Rune)
+	case syntax.OpCharClass:
+		return string(pickFromCharClass(re.Rune, variant))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return "a"
+	case syntax.OpCapture:
+		return generateFromRegexp(re.Sub[0], extra, variant)
+	case syntax.OpConcat:
+		builder := new(strings.Builder)
+		for _, sub := range re.Sub {
+			builder.WriteString(generateFromRegexp(sub, extra, variant))
+		}
+
+		return builder.String()
+	case syntax.OpAlternate:
+		return generateFromRegexp(re.Sub[variant%len(re.Sub)], extra, variant)
+	case syntax.OpStar:
+		return strings.Repeat(generateFromRegexp(re.Sub[0], extra, variant), extra)
+	case syntax.OpPlus:
+		return strings.Repeat(generateFromRegexp(re.Sub[0], extra, variant), 1+extra)
+	case syntax.OpQuest:
+		if extra > 0 {
+			return generateFromRegexp(re.Sub[0], extra, variant)
+		}
+
+		return ""
+	case syntax.OpRepeat:
+		count := re.Min + extra
+		if re.Max >= 0 && count > re.Max {
+			count = re.Max
+		}
+
+		return strings.Repeat(generateFromRegexp(re.Sub[0], extra, variant), count)
+	default:
+		// Anchors, word boundaries, and empty matches don't produce any output
+		return ""
+	}
+}
+
+// pickFromCharClass chooses a readable character from a character class, preferring lowercase letters, then
+// uppercase letters, then digits; the variant moves along that list
+func pickFromCharClass(ranges []rune, variant int) rune {
+	if len(ranges) < 2 {
+		return 'a'
+	}
+
+	readable := make([]rune, 0)
+	for _, group := range [][2]rune{{'a', 'z'}, {'A', 'Z'}, {'0', '9'}} {
+		for char := group[0]; char <= group[1]; char++ {
+			if charClassContains(ranges, char) {
+				readable = append(readable, char)
+			}
+		}
+	}
+
+	if len(readable) > 0 {
+		return readable[variant%len(readable)]
+	}
+
+	for idx := 0; idx+1 < len(ranges); idx += 2 {
+		for char := ranges[idx]; char <= ranges[idx+1] && char < ranges[idx]+256; char++ {
+			if unicode.IsLetter(char) || unicode.IsDigit(char) {
+				readable = append(readable, char)
+			}
+		}
+	}
+
+	if len(readable) > 0 {
+		return readable[variant%len(readable)]
+	}
+
+	return ranges[0]
+}
+
+func charClassContains(ranges []rune, char rune) bool {
+	for idx := 0; idx+1 < len(ranges); idx += 2 {
+		if ranges[idx] <= char && char <= ranges[idx+1] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// encodeFormPayload serializes an object as application/x-www-form-urlencoded. Each property uses the style and
+// explode settings from the media type's encoding map (defaulting to exploded `form`), and complex values with a
+// configured contentType are serialized as that type first.
+func encodeFormPayload(data interface{}, encoding map[string]*v3.Encoding) (string, error) {
+	// Don't serialize a string that's already serialized
+	if stringData, isType := data.(string); isType {
+		return stringData, nil
+	}
+
+	object, isObject := payloadToObject(data)
+	if !isObject {
+		return "", fmt.Errorf("Can't encode a %T as form data; only objects are supported\n", data)
+	}
+
+	values := make(url.Values)
+
+	for name, value := range object {
+		style, explode := getParamStyle("query", "", nil)
+
+		if settings := encoding[name]; settings != nil {
+			style, explode = getParamStyle("query", settings.Style, settings.Explode)
+
+			if settings.ContentType != "" && isComplexPayload(value) {
+				encoded, err := getPayloadFromType(settings.ContentType, value, nil)
+				if err != nil {
+					return "", err
+				}
+
+				value = encoded
+			}
+		}
+
+		for key, list := range serializeQueryParam(name, value, style, explode) {
+			values[key] = append(values[key], list...)
+		}
+	}
+
+	return values.Encode(), nil
+}
+
+// payloadToObject converts any map with string-like keys into a map[string]interface{}
+func payloadToObject(data interface{}) (map[string]interface{}, bool) {
+	if object, isType := data.(map[string]interface{}); isType {
+		return object, true
+	}
+
+	reflected := reflect.ValueOf(data)
+	if reflected.Kind() != reflect.Map {
+		return nil, false
+	}
+
+	object := make(map[string]interface{}, reflected.Len())
+	for _, key := range reflected.MapKeys() {
+		object[renderParamValue(key.Interface())] = reflected.MapIndex(key).Interface()
+	}
+
+	return object, true
+}
+
+// isComplexPayload reports whether a value is an object or array, rather than a primitive
+func isComplexPayload(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		_, isBytes := value.([]byte)
+		return !isBytes
+	default:
+		return false
+	}
+}
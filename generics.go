@@ -1,6 +1,10 @@
 package main
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
 type ServerVarsConfig map[string]string
 
@@ -10,9 +14,36 @@ type PathsConfig map[string]PathConfig
 
 type PathConfig map[string]PathMethodConfig
 
+type ParamsConfig map[string]ParamConfig
+
+type SiegeSettingsConfig map[string]interface{}
+
+// PayloadConfig is a configured request body: either inline, or read from a file given as `@file:{path}` or
+// `{file = "{path}"}`
+type PayloadConfig struct {
+	Value string `json:"value,omitempty"`
+	File  string `json:"file,omitempty"`
+}
+
+// The prefix marking an inline payload as a file reference instead
+const payloadFilePrefix = "@file:"
+
+// ParamConfig is a configured parameter (or payload field) value: either inline, or drawn from a column of a CSV or
+// JSON lines data file, as `{from = "{path}", column = "{name}"}`. Data files can override `generate.dataLimit` and
+// `generate.dataSample` with `limit` (where 0 uses every row) and `sample`.
+type ParamConfig struct {
+	Value  string `json:"value,omitempty"`
+	From   string `json:"from,omitempty"`
+	Column string `json:"column,omitempty"`
+	Limit  *int   `json:"limit,omitempty"`
+	Sample string `json:"sample,omitempty"`
+}
+
 type PathMethodConfig struct {
-	Params   map[string]string `json:"params"`
-	Payloads map[string]string `json:"payloads"`
+	Params   map[string]ParamConfig   `json:"params"`
+	Payloads map[string]PayloadConfig `json:"payloads"`
+	Files    map[string]string        `json:"files"`
+	Fields   map[string]ParamConfig   `json:"fields"`
 }
 
 func (c ServerVarsConfig) Set(value string) error {
@@ -65,3 +96,102 @@ func (c PathsConfig) String() string {
 func (c PathsConfig) FromJson(raw []byte) error {
 	return json.Unmarshal(raw, &c)
 }
+
+func (c ParamsConfig) Set(value string) error {
+	return json.Unmarshal([]byte(value), &c)
+}
+
+func (c ParamsConfig) String() string {
+	value, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+
+	return string(value)
+}
+
+func (c ParamsConfig) FromJson(raw []byte) error {
+	return json.Unmarshal(raw, &c)
+}
+
+func (c SiegeSettingsConfig) Set(value string) error {
+	return json.Unmarshal([]byte(value), &c)
+}
+
+func (c SiegeSettingsConfig) String() string {
+	value, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+
+	return string(value)
+}
+
+func (c SiegeSettingsConfig) FromJson(raw []byte) error {
+	return json.Unmarshal(raw, &c)
+}
+
+func (c *PayloadConfig) UnmarshalJSON(raw []byte) error {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		if strings.HasPrefix(value, payloadFilePrefix) {
+			*c = PayloadConfig{File: strings.TrimPrefix(value, payloadFilePrefix)}
+		} else {
+			*c = PayloadConfig{Value: value}
+		}
+
+		return nil
+	}
+
+	// Avoid recursing back into this method
+	type payloadConfig PayloadConfig
+
+	var object payloadConfig
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return fmt.Errorf("payloads must be strings, or objects with a `file` key: %v", err)
+	}
+
+	*c = PayloadConfig(object)
+
+	return nil
+}
+
+func (c PayloadConfig) MarshalJSON() ([]byte, error) {
+	if c.File != "" {
+		return json.Marshal(payloadFilePrefix + c.File)
+	}
+
+	return json.Marshal(c.Value)
+}
+
+func (c *ParamConfig) UnmarshalJSON(raw []byte) error {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		*c = ParamConfig{Value: value}
+
+		return nil
+	}
+
+	// Avoid recursing back into this method
+	type paramConfig ParamConfig
+
+	var object paramConfig
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return fmt.Errorf("params must be strings, or objects with a `from` key: %v", err)
+	}
+
+	*c = ParamConfig(object)
+
+	return nil
+}
+
+func (c ParamConfig) MarshalJSON() ([]byte, error) {
+	if c.From == "" {
+		return json.Marshal(c.Value)
+	}
+
+	// Avoid recursing back into this method
+	type paramConfig ParamConfig
+
+	return json.Marshal(paramConfig(c))
+}
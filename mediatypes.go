@@ -0,0 +1,115 @@
+package main
+
+import (
+	"mime"
+	"strings"
+)
+
+// Media types whose bodies are newline-delimited JSON documents
+var ndjsonMediaTypes = []string{"application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines"}
+
+// parseMediaType strips any parameters (such as `; charset=utf-8`) from a media type, and finds its structured syntax
+// suffix, as described in RFC 6839; `application/vnd.api+json` has the suffix `json`
+func parseMediaType(mediatype string) (string, string) {
+	baseType, _, err := mime.ParseMediaType(mediatype)
+	if err != nil {
+		baseType, _, _ = strings.Cut(mediatype, ";")
+		baseType = strings.TrimSpace(strings.ToLower(baseType))
+	}
+
+	_, subtype, _ := strings.Cut(baseType, "/")
+	if idx := strings.LastIndex(subtype, "+"); idx >= 0 {
+		return baseType, subtype[idx+1:]
+	}
+
+	return baseType, ""
+}
+
+// isMediaType reports whether a media type is the given base type, or uses it as its structured syntax suffix
+func isMediaType(mediatype, baseType string) bool {
+	parsed, suffix := parseMediaType(mediatype)
+	if parsed == baseType {
+		return true
+	}
+
+	_, baseSubtype, _ := strings.Cut(baseType, "/")
+
+	return suffix != "" && suffix == baseSubtype
+}
+
+func isJSONMediaType(mediatype string) bool {
+	return isMediaType(mediatype, "application/json")
+}
+
+func isNDJSONMediaType(mediatype string) bool {
+	baseType, _ := parseMediaType(mediatype)
+
+	for _, ndjsonType := range ndjsonMediaTypes {
+		if baseType == ndjsonType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isTextMediaType(mediatype string) bool {
+	baseType, _ := parseMediaType(mediatype)
+
+	return baseType == "text/plain"
+}
+
+func isFormMediaType(mediatype string) bool {
+	baseType, _ := parseMediaType(mediatype)
+
+	return baseType == "application/x-www-form-urlencoded"
+}
+
+// isXMLMediaType reports whether request bodies of this media type are XML documents
+func isXMLMediaType(mediatype string) bool {
+	return isMediaType(mediatype, "application/xml") || isMediaType(mediatype, "text/xml")
+}
+
+// isMultipartMediaType reports whether request bodies of this media type are multipart/form-data
+func isMultipartMediaType(mediatype string) bool {
+	baseType, _ := parseMediaType(mediatype)
+
+	return baseType == "multipart/form-data"
+}
+
+// mediaTypeFilePrefix names the output files for a media type after its suffix, or its subtype if it has no suffix, so
+// `application/problem+json` bodies go in `json.urls.txt`
+func mediaTypeFilePrefix(mediatype string) string {
+	baseType, suffix := parseMediaType(mediatype)
+	if suffix != "" {
+		return suffix
+	}
+
+	_, subtype, _ := strings.Cut(baseType, "/")
+
+	return subtype
+}
+
+// mediaTypeFilePrefixes names the output files for each media type, using the full subtype for any that would share
+// a prefix with another, so `application/json` and `application/vnd.api+json` don't overwrite each other's files
+func mediaTypeFilePrefixes(mediatypes []string) map[string]string {
+	counts := make(map[string]int)
+	for _, mediatype := range mediatypes {
+		counts[mediaTypeFilePrefix(mediatype)]++
+	}
+
+	prefixes := make(map[string]string)
+	for _, mediatype := range mediatypes {
+		prefix := mediaTypeFilePrefix(mediatype)
+
+		if counts[prefix] > 1 {
+			baseType, _ := parseMediaType(mediatype)
+			_, subtype, _ := strings.Cut(baseType, "/")
+			prefix = strings.ReplaceAll(subtype, "+", ".")
+		}
+
+		prefixes[mediatype] = prefix
+	}
+
+	return prefixes
+}
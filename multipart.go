@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Siege can't generate boundaries, so every multipart body uses the same one
+const multipartBoundary = "oa2s-boundary-7MA4YWxkTrZu0gW"
+
+var multipartQuoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartPart is a single field of a multipart/form-data body
+type multipartPart struct {
+	Name        string
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// getPayloadMediaType adds the boundary to multipart media types, since Siege sends the content type as given
+func getPayloadMediaType(mediatype string) string {
+	if !isMultipartMediaType(mediatype) {
+		return mediatype
+	}
+
+	return mime.FormatMediaType("multipart/form-data", map[string]string{"boundary": multipartBoundary})
+}
+
+// writeMultipartPayload assembles parts into a multipart/form-data body, and saves it to a payload file
+func writeMultipartPayload(c *cli.Context, parts []multipartPart) (string, error) {
+	body := new(bytes.Buffer)
+
+	writer := multipart.NewWriter(body)
+	if err := writer.SetBoundary(multipartBoundary); err != nil {
+		return "", err
+	}
+
+	for _, part := range parts {
+		header := make(textproto.MIMEHeader)
+
+		disposition := fmt.Sprintf(`form-data; name="%s"`, multipartQuoteEscaper.Replace(part.Name))
+		if part.FileName != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, multipartQuoteEscaper.Replace(part.FileName))
+		}
+		header.Set("Content-Disposition", disposition)
+
+		if part.ContentType != "" {
+			header.Set("Content-Type", part.ContentType)
+		}
+
+		partWriter, err := writer.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err = partWriter.Write(part.Content); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return writePayloadFile(c, body.Bytes(), ".multipart")
+}
+
+// newMultipartTextPart serializes a value as a non-file part. Objects and arrays default to JSON, and primitives to
+// plain text, unless the encoding map configures another content type.
+func newMultipartTextPart(name string, value interface{}, contentType string) (multipartPart, error) {
+	contentType = firstContentType(contentType)
+
+	if contentType == "" && isComplexPayload(value) {
+		contentType = "application/json"
+	}
+
+	if contentType == "" || strings.HasPrefix(contentType, "text/plain") {
+		return multipartPart{Name: name, ContentType: contentType, Content: []byte(renderParamValue(value))}, nil
+	}
+
+	content, err := getPayloadFromType(contentType, value, nil)
+	if err != nil {
+		return multipartPart{}, err
+	}
+
+	return multipartPart{Name: name, ContentType: contentType, Content: []byte(content)}, nil
+}
+
+// newMultipartFilePart reads a part's contents from its configured sample file, or else generates `generate.binarySize`
+// bytes for it
+func newMultipartFilePart(c *cli.Context, name, contentType, sampleFile string) (multipartPart, error) {
+	contentType = firstContentType(contentType)
+
+	if sampleFile != "" {
+		content, err := os.ReadFile(sampleFile)
+		if err != nil {
+			return multipartPart{}, fmt.Errorf("Could not read the sample file for the %s part\n\t%v\n", name, err)
+		}
+
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(sampleFile))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		return multipartPart{Name: name, FileName: filepath.Base(sampleFile), ContentType: contentType, Content: content}, nil
+	}
+
+	size := c.Int("generate.binarySize")
+	if size < 0 {
+		return multipartPart{}, fmt.Errorf("Invalid binary size %d\n\tCheck your configuration for `generate.binarySize`\n", size)
+	}
+
+	content := make([]byte, size)
+	for idx := range content {
+		content[idx] = byte(idx)
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return multipartPart{Name: name, FileName: name + ".bin", ContentType: contentType, Content: content}, nil
+}
+
+// firstContentType picks the first of a comma-separated list of content types, as allowed in encoding objects
+func firstContentType(contentType string) string {
+	first, _, _ := strings.Cut(contentType, ",")
+
+	return strings.TrimSpace(first)
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// oauth2Flow is a token-granting flow we can perform without a browser
+type oauth2Flow struct {
+	Grant    string
+	TokenUrl string
+}
+
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type openIdConfiguration struct {
+	TokenEndpoint       string   `json:"token_endpoint"`
+	GrantTypesSupported []string `json:"grant_types_supported"`
+}
+
+var oauth2HttpClient = &http.Client{Timeout: 30 * time.Second}
+
+// applyOAuth2Auth acquires a bearer token using one of the given flows, and adds it to the Siege config. Alternately,
+// when `auth.{name}.mode` is `script`, it writes a script to fetch a fresh token (into the output directory, unless
+// `auth.{name}.script` says otherwise), and references its output instead.
+func applyOAuth2Auth(name string, flows []oauth2Flow, scopes []string, auth AuthConfig, outDir string, conf *SiegeConfig) error {
+	creds := auth[name]
+
+	flow, err := chooseOAuth2Flow(name, flows, creds)
+	if err != nil {
+		return err
+	}
+
+	if tokenUrl, exists := creds["token_url"]; exists {
+		flow.TokenUrl = tokenUrl
+	}
+	if flow.TokenUrl == "" {
+		return fmt.Errorf("No token URL defined for the %s scheme\n\tNeed `auth.%s.token_url`\n", name, name)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", flow.Grant)
+
+	requestedScopes := append([]string{}, scopes...)
+	if extraScopes, exists := creds["scopes"]; exists {
+		requestedScopes = append(requestedScopes, strings.Fields(extraScopes)...)
+	}
+	if len(requestedScopes) > 0 {
+		form.Set("scope", strings.Join(uniqueSlice(requestedScopes), " "))
+	}
+
+	if flow.Grant == "password" {
+		form.Set("username", creds["username"])
+		form.Set("password", creds["password"])
+	}
+
+	clientInBody := creds["client_auth"] == "body"
+	if clientInBody {
+		form.Set("client_id", creds["client_id"])
+		if creds["client_secret"] != "" {
+			form.Set("client_secret", creds["client_secret"])
+		}
+	}
+
+	switch creds["mode"] {
+	case "", "token":
+		token, err := fetchOAuth2Token(name, flow.TokenUrl, form, creds, clientInBody)
+		if err != nil {
+			return err
+		}
+
+		conf.Headers.Set("Authorization", fmt.Sprintf("Bearer %s", conf.Secret(name, "token", token.AccessToken)))
+
+		if token.ExpiresIn > 0 {
+			fmt.Printf("The OAuth2 token for %s expires in %s.\n\tSiege does NOT refresh tokens; regenerate your config, or use `auth.%s.mode = \"script\"`, for longer runs.\n", name, time.Duration(token.ExpiresIn)*time.Second, name)
+		}
+	case "script":
+		variable := siegeVariableName("token", name)
+
+		scriptFile := creds["script"]
+		if scriptFile == "" {
+			scriptFile = filepath.Join(outDir, fmt.Sprintf("oa2s-token-%s.sh", name))
+		}
+
+		if err := os.WriteFile(scriptFile, []byte(getOAuth2Script(name, flow.TokenUrl, form, creds, clientInBody)), 0700); err != nil {
+			return err
+		}
+
+		conf.Headers.Set("Authorization", fmt.Sprintf("Bearer ${%s}", variable))
+		fmt.Printf("OAuth2 tokens for %s are fetched by %s.\n\tBefore each Siege run, use `export %s=$(sh %s)` to set a fresh token.\n", name, scriptFile, variable, scriptFile)
+
+		if secrets := maps.Values(oauth2SecretVariables(name, form, creds)); len(secrets) > 0 {
+			slices.Sort(secrets)
+			fmt.Printf("\tThe script reads its secrets from %s; set them first.\n", strings.Join(secrets, " and "))
+		}
+	default:
+		return fmt.Errorf("Unknown OAuth2 mode `%s` for %s scheme\n\tNeed `auth.%s.mode` to be `token` or `script`\n", creds["mode"], name, name)
+	}
+
+	return nil
+}
+
+// chooseOAuth2Flow picks the configured grant, or infers one from the configured credentials
+func chooseOAuth2Flow(name string, flows []oauth2Flow, creds map[string]string) (oauth2Flow, error) {
+	grant := creds["grant"]
+	if grant == "" {
+		grant = "client_credentials"
+
+		if creds["username"] != "" && creds["password"] != "" {
+			grant = "password"
+		}
+	}
+
+	idx := slices.IndexFunc(flows, func(flow oauth2Flow) bool {
+		return flow.Grant == grant
+	})
+	if idx < 0 {
+		return oauth2Flow{}, fmt.Errorf("The %s scheme doesn't offer the OAuth2 `%s` grant\n\tOnly `client_credentials` and `password` grants are supported; check your configuration for `auth.%s.grant`\n", name, grant, name)
+	}
+
+	switch grant {
+	case "client_credentials":
+		if creds["client_id"] == "" {
+			return oauth2Flow{}, fmt.Errorf("Client credentials not configured for %s scheme\n\tNeed `auth.%s.client_id` and `auth.%s.client_secret`\n", name, name, name)
+		}
+	case "password":
+		if creds["username"] == "" || creds["password"] == "" {
+			return oauth2Flow{}, fmt.Errorf("User credentials not configured for %s scheme\n\tNeed `auth.%s.username` and `auth.%s.password`\n", name, name, name)
+		}
+	}
+
+	return flows[idx], nil
+}
+
+// getOpenIdConnectFlows reads the token endpoint and supported grants from an OpenID Connect discovery document. With
+// `auth.{name}.token_url` configured, the document isn't needed, so every supported grant is offered at that URL.
+func getOpenIdConnectFlows(name, discoveryUrl string, creds map[string]string) ([]oauth2Flow, error) {
+	if tokenUrl := creds["token_url"]; tokenUrl != "" {
+		return []oauth2Flow{{Grant: "client_credentials", TokenUrl: tokenUrl}, {Grant: "password", TokenUrl: tokenUrl}}, nil
+	}
+
+	response, err := oauth2HttpClient.Get(discoveryUrl)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load the OpenID Connect discovery document for %s\n\t%v\n", name, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("Could not load the OpenID Connect discovery document for %s\n\t%s returned %s\n", name, discoveryUrl, response.Status)
+	}
+
+	var discovery openIdConfiguration
+	if err = json.NewDecoder(response.Body).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("Could not parse the OpenID Connect discovery document for %s\n\t%v\n", name, err)
+	}
+
+	flows := make([]oauth2Flow, 0)
+
+	for _, grant := range []string{"client_credentials", "password"} {
+		// Providers which don't list their grants are assumed to support them all
+		if len(discovery.GrantTypesSupported) < 1 || slices.Contains(discovery.GrantTypesSupported, grant) {
+			flows = append(flows, oauth2Flow{Grant: grant, TokenUrl: discovery.TokenEndpoint})
+		}
+	}
+
+	return flows, nil
+}
+
+func fetchOAuth2Token(name, tokenUrl string, form url.Values, creds map[string]string, clientInBody bool) (*oauth2TokenResponse, error) {
+	request, err := http.NewRequest(http.MethodPost, tokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	if !clientInBody && creds["client_id"] != "" {
+		request.SetBasicAuth(url.QueryEscape(creds["client_id"]), url.QueryEscape(creds["client_secret"]))
+	}
+
+	response, err := oauth2HttpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Could not fetch an OAuth2 token for %s\n\t%v\n", name, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var token oauth2TokenResponse
+	if err = json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		if token.Error != "" {
+			return nil, fmt.Errorf("Could not fetch an OAuth2 token for %s\n\t%s: %s\n", name, token.Error, token.ErrorDescription)
+		}
+
+		return nil, fmt.Errorf("Could not fetch an OAuth2 token for %s\n\t%s returned %s: %s\n", name, tokenUrl, response.Status, body)
+	}
+
+	return &token, nil
+}
+
+// getOAuth2Script writes a shell script fetching a token. Secrets (the client secret and password) aren't written into
+// the script; it reads them from environment variables instead, named by oauth2SecretVariables.
+func getOAuth2Script(name, tokenUrl string, form url.Values, creds map[string]string, clientInBody bool) string {
+	writer := new(strings.Builder)
+
+	writer.WriteString("#!/bin/sh\n")
+	writer.WriteString(fmt.Sprintf("# Fetches a fresh OAuth2 token for the %s security scheme, and prints it to stdout\n", name))
+	writer.WriteString("curl -sS -X POST -H 'Accept: application/json' \\\n")
+
+	public := url.Values{}
+	for key, values := range form {
+		public[key] = values
+	}
+
+	secrets := oauth2SecretVariables(name, form, creds)
+	for key := range secrets {
+		public.Del(key)
+	}
+
+	if !clientInBody && creds["client_id"] != "" {
+		clientSecret := ""
+		if variable, exists := secrets["client_secret"]; exists {
+			clientSecret = fmt.Sprintf("\"${%s:?}\"", variable)
+		}
+
+		writer.WriteString(fmt.Sprintf("  -u %s%s \\\n", shellQuote(url.QueryEscape(creds["client_id"])+":"), clientSecret))
+	}
+
+	writer.WriteString(fmt.Sprintf("  --data %s \\\n", shellQuote(public.Encode())))
+
+	for _, key := range []string{"client_secret", "password"} {
+		if variable, exists := secrets[key]; exists && form.Has(key) {
+			writer.WriteString(fmt.Sprintf("  --data-urlencode \"%s=${%s:?}\" \\\n", key, variable))
+		}
+	}
+
+	writer.WriteString(fmt.Sprintf("  %s \\\n", shellQuote(tokenUrl)))
+	writer.WriteString("  | sed -n 's/.*\"access_token\" *: *\"\\([^\"]*\\)\".*/\\1/p'\n")
+
+	return writer.String()
+}
+
+// oauth2SecretVariables names the environment variables a token script reads its secrets from, keyed by the form field
+// (or credential) they hold
+func oauth2SecretVariables(name string, form url.Values, creds map[string]string) map[string]string {
+	secrets := make(map[string]string)
+
+	if creds["client_secret"] != "" {
+		secrets["client_secret"] = siegeVariableName("client_secret", name)
+	}
+
+	if form.Has("password") {
+		secrets["password"] = siegeVariableName("password", name)
+	}
+
+	return secrets
+}
+
+func shellQuote(value string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", `'\''`))
+}
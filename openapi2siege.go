@@ -5,15 +5,27 @@ import (
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/resolver"
 	"github.com/pb33f/libopenapi/utils"
 	"github.com/urfave/cli/v2"
 	"github.com/urfave/cli/v2/altsrc"
 )
 
 func main() {
+	err := newApp().Run(os.Args)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newApp builds the command line app; each profile generated by `--all-profiles` runs in an app of its own
+func newApp() *cli.App {
 	app := cli.NewApp()
 	app.Name = "openapi2siege"
 	app.Version = "0.1.0"
@@ -37,6 +49,25 @@ func main() {
 			Value:     "oa2s.conf",
 			TakesFile: true,
 		},
+		&cli.StringFlag{
+			Name:    "profile",
+			Aliases: []string{"p"},
+			Usage:   "overlay the configuration with the profile of this `name` (profiles.{name} in the configuration file), and prefix the generated files with it",
+		},
+		&cli.BoolFlag{
+			Name:  "all-profiles",
+			Usage: "generate every profile in the configuration file, each into its own directory",
+		},
+		&cli.PathFlag{
+			Name:      "outdir",
+			Aliases:   []string{"o"},
+			Usage:     "write the generated files into this `directory`",
+			TakesFile: true,
+		},
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:  "auto",
+			Usage: "fill in every unconfigured parameter and payload from examples, defaults, or generated values, skipping (and listing) operations which still can't be converted",
+		}),
 		// OpenAPI-related configs
 		altsrc.NewPathFlag(&cli.PathFlag{
 			Name:      "spec",
@@ -67,12 +98,67 @@ func main() {
 			Value:  AuthConfig{},
 			Hidden: true,
 		}),
+		altsrc.NewGenericFlag(&cli.GenericFlag{
+			Name:   "params",
+			Usage:  "configure default parameter values by name for every operation: params.{name} (inline, or {from: file, column: name})",
+			Value:  ParamsConfig{},
+			Hidden: true,
+		}),
 		altsrc.NewGenericFlag(&cli.GenericFlag{
 			Name:   "paths",
-			Usage:  "configure path details: paths.{path}.{method}.params.{name}, paths.{path}.post.payloads.{mediatype}",
+			Usage:  "configure path details, where {path} can also be a glob (/users/* or /users/**) or a regular expression prefixed with ~, and {method} can be *: paths.{path}.{method}.params.{name} (inline, or {from: file, column: name}), paths.{path}.post.fields.{name}, paths.{path}.post.payloads.{mediatype} (inline, `@file:{path}`, or {file: path}), paths.{path}.post.files.{name}",
 			Value:  PathsConfig{},
 			Hidden: true,
 		}),
+		// Generator-related configs
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:   "generate.enums",
+			Usage:  "pick enum members for generated values: `first`, `random`, or `round-robin` (one URL per member)",
+			Value:  enumsFirst,
+			Hidden: true,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:   "generate.properties",
+			Usage:  "include `all` object properties in generated values, or only `required` ones",
+			Value:  propertiesAll,
+			Hidden: true,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:   "generate.branches",
+			Usage:  "generate the `first` branch of oneOf/anyOf schemas, or `all` of them (one URL per branch)",
+			Value:  branchesFirst,
+			Hidden: true,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:   "generate.examples",
+			Usage:  "combine named parameter and body examples by taking the `first` of each, pairing them up (`zip`), or taking every combination (`product`)",
+			Value:  examplesZip,
+			Hidden: true,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:   "generate.dataLimit",
+			Usage:  "use at most this many rows of each parameter data file; 0 uses them all",
+			Value:  100,
+			Hidden: true,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:   "generate.dataSample",
+			Usage:  "pick limited data file rows from the start (`first`), spread through the file (`even`), or at `random`",
+			Value:  sampleFirst,
+			Hidden: true,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:   "generate.binarySize",
+			Usage:  "generate this many bytes for file uploads without a sample file",
+			Value:  1024,
+			Hidden: true,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:   "generate.maxDepth",
+			Usage:  "stop generating nested values this many schemas deep",
+			Value:  10,
+			Hidden: true,
+		}),
 		// Siege-related configs
 		altsrc.NewPathFlag(&cli.PathFlag{
 			Name:      "siege.urls",
@@ -88,6 +174,31 @@ func main() {
 			TakesFile: true,
 			Hidden:    true,
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:   "siege.variables",
+			Usage:  "write the base URL, server variables, and credentials as Siege variables in the siege.conf (`conf`), leave their values to the environment (`env`), or write them directly into each URL and header (`off`)",
+			Value:  variablesOff,
+			Hidden: true,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:   "siege.cookieLifetime",
+			Usage:  "expire cookies without an expiry time of their own after this `duration`",
+			Value:  30 * 24 * time.Hour,
+			Hidden: true,
+		}),
+		altsrc.NewPathFlag(&cli.PathFlag{
+			Name:      "siege.payloads",
+			Usage:     "specify the directory `path` for payloads too complex to include in the urls.txt",
+			Value:     "payloads",
+			TakesFile: true,
+			Hidden:    true,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:   "siege.inlineLimit",
+			Usage:  "move generated payloads larger than this many bytes into the payloads directory; 0 keeps them inline",
+			Value:  4096,
+			Hidden: true,
+		}),
 		altsrc.NewPathFlag(&cli.PathFlag{
 			Name:      "siege.config",
 			Usage:     "specify the `path` of the siege.conf to generate",
@@ -97,17 +208,55 @@ func main() {
 		}),
 	}
 
+	app.Flags = append(app.Flags, siegeSettingsFlags()...)
+
 	app.Before = func(ctx *cli.Context) error {
+		explicitOutputs := explicitOutputFlags(ctx)
+
 		if !ctx.IsSet("conf") {
+			// Automatic conversion doesn't need a configuration file, so only use the default one if it's there
+			if _, err := os.Stat("oa2s.conf"); err != nil && ctx.Bool("auto") {
+				return applyOutputPaths(ctx, explicitOutputs, false)
+			}
+
 			if err := ctx.Set("conf", "oa2s.conf"); err != nil {
 				return err
 			}
 		}
 
-		return altsrc.InitInputSourceWithContext(app.Flags, altsrc.DetectNewSourceFromFlagFunc("conf"))(ctx)
+		// Anything set on the command line stays relative to the working directory
+		paramsFromFile, pathsFromFile, authFromFile := !ctx.IsSet("params"), !ctx.IsSet("paths"), !ctx.IsSet("auth")
+
+		if err := altsrc.InitInputSourceWithContext(app.Flags, newProfileSourceFromFlagFunc("conf", "profile"))(ctx); err != nil {
+			return err
+		}
+
+		// Files named in the configuration file are relative to it, like its path flags
+		confDir, err := filepath.Abs(filepath.Dir(ctx.Path("conf")))
+		if err != nil {
+			return err
+		}
+
+		if params, isType := ctx.Generic("params").(ParamsConfig); isType && paramsFromFile {
+			params.resolveFilePaths(confDir)
+		}
+
+		if paths, isType := ctx.Generic("paths").(PathsConfig); isType && pathsFromFile {
+			paths.resolveFilePaths(confDir)
+		}
+
+		if auth, isType := ctx.Generic("auth").(AuthConfig); isType && authFromFile {
+			auth.resolveFilePaths(confDir)
+		}
+
+		return applyOutputPaths(ctx, explicitOutputs, true)
 	}
 
 	app.Action = func(c *cli.Context) error {
+		if c.Bool("all-profiles") {
+			return generateAllProfiles(c)
+		}
+
 		specPath := c.Path("spec")
 
 		specBytes, err := os.ReadFile(specPath)
@@ -120,39 +269,26 @@ func main() {
 			return fmt.Errorf("Could not parse %s\n%v\n", specPath, err)
 		}
 
-		var urls urlList
-		var conf *SiegeConfig
+		var groups []authGroup
 
 		switch specDoc.GetSpecInfo().SpecType {
 		case utils.OpenApi2:
 			specV2, errs := specDoc.BuildV2Model()
-			if len(errs) > 0 {
-				for _, err = range errs {
-					if err != nil {
-						fmt.Printf("Could not load v2 spec in %s\n%v\n", specPath, err)
-					}
-				}
-
+			if !reportSpecErrors("v2", specPath, errs) {
 				return fmt.Errorf("Aborting.\n")
 			}
 
-			urls, conf, err = handleV2Spec(c, specV2)
+			groups, err = handleV2Spec(c, specV2)
 			if err != nil {
 				return err
 			}
 		case utils.OpenApi3:
 			specV3, errs := specDoc.BuildV3Model()
-			if len(errs) > 0 {
-				for _, err = range errs {
-					if err != nil {
-						fmt.Printf("Could not load v3 spec in %s\n%v\n", specPath, err)
-					}
-				}
-
+			if !reportSpecErrors("v3", specPath, errs) {
 				return fmt.Errorf("Aborting.\n")
 			}
 
-			urls, conf, err = handleV3Spec(c, specV3)
+			groups, err = handleV3Spec(c, specV3)
 			if err != nil {
 				return err
 			}
@@ -160,67 +296,117 @@ func main() {
 			return fmt.Errorf("Could not load spec in %s\nUnknown Spec Type %s\n", specPath, specDoc.GetSpecInfo().SpecType)
 		}
 
-		conf.GetMethod = "GET"
+		for _, group := range groups {
+			if err = writeAuthGroup(c, group, len(groups) > 1); err != nil {
+				return err
+			}
+		}
 
-		urlFile := c.Path("siege.urls")
-		configFile := c.Path("siege.config")
-		cookieFile := c.Path("siege.cookies")
+		fmt.Println("")
 
-		cookies, err := urls.CookieJar(cookieFile)
-		if err != nil {
+		return nil
+	}
+
+	return app
+}
+
+// writeAuthGroup writes the urls.txt, cookies.txt, and siege.conf files for a single group of credentials
+func writeAuthGroup(c *cli.Context, group authGroup, multipleGroups bool) error {
+	urls := group.URLs
+	conf := group.Config
+
+	conf.GetMethod = "GET"
+
+	if err := conf.ApplySettings(c); err != nil {
+		return err
+	}
+
+	urlFile := c.Path("siege.urls")
+	configFile := c.Path("siege.config")
+	cookieFile := c.Path("siege.cookies")
+
+	if multipleGroups {
+		urlFile = prefixFilename(group.Name, urlFile)
+		configFile = prefixFilename(group.Name, configFile)
+		cookieFile = prefixFilename(group.Name, cookieFile)
+	}
+
+	cookies := urls.SiegeCookies(c.Duration("siege.cookieLifetime"))
+	if len(cookies) > 0 {
+		if err := os.WriteFile(cookieFile, []byte(siegeCookieFile(cookies)), os.ModePerm); err != nil {
 			return err
 		}
-		if err = cookies.Save(); err != nil {
+
+		conf.CookieFile = cookieFile
+		fmt.Printf("\nSiege has no setting for its cookies file, and always reads ~/.siege/cookies.txt\n\tCopy %s there (or merge it into the existing file) by hand before running Siege\n", cookieFile)
+	}
+
+	types := urls.MediaTypes()
+	multipleTypes := len(types) > 1
+	prefixes := mediaTypeFilePrefixes(types)
+
+	if len(types) < 1 {
+		types = []string{""}
+	}
+
+	for _, mediaType := range types {
+		myUrlFile := urlFile
+		myConfigFile := configFile
+
+		if multipleTypes {
+			myUrlFile = prefixFilename(prefixes[mediaType], myUrlFile)
+			myConfigFile = prefixFilename(prefixes[mediaType], myConfigFile)
+		}
+
+		if err := os.WriteFile(myUrlFile, []byte(conf.ReplaceBaseURL(urls.StringByMediaType(mediaType))), os.ModePerm); err != nil {
 			return err
 		}
 
-		types := urls.MediaTypes()
-		multipleTypes := len(types) > 1
+		conf.UrlFile = myUrlFile
 
-		if len(types) < 1 {
-			types = []string{""}
+		if err := os.WriteFile(myConfigFile, []byte(conf.String()), os.ModePerm); err != nil {
+			return err
 		}
 
-		for _, mediaType := range types {
-			myUrlFile := urlFile
-			myConfigFile := configFile
+		if variables := conf.EnvironmentVariables(); len(variables) > 0 {
+			fmt.Printf("\n%s reads %s from the environment; set them before running Siege\n", myConfigFile, strings.Join(variables, ", "))
+		}
 
-			if multipleTypes {
-				splitType := strings.Split(mediaType, "/")
-				furtherSplitType := strings.Split(splitType[len(splitType)-1], "+")
-				prefix := furtherSplitType[len(furtherSplitType)-1]
+		command := fmt.Sprintf("siege -R %s", myConfigFile)
+		if mediaType != "" {
+			command = fmt.Sprintf("%s -T '%s'", command, mediaType)
+		}
 
-				myUrlFile = prefixFilename(prefix, myUrlFile)
-				myConfigFile = prefixFilename(prefix, myConfigFile)
-			}
+		if conf.CookieFile != "" {
+			command = fmt.Sprintf("mkdir -p ~/.siege && cp %s ~/.siege/cookies.txt\n\t%s", conf.CookieFile, command)
+		}
 
-			if err = os.WriteFile(myUrlFile, []byte(urls.StringByMediaType(mediaType)), os.ModePerm); err != nil {
-				return err
-			}
+		fmt.Printf("\nConversion complete! To use, run\n\t%s\n", command)
+	}
 
-			conf.UrlFile = myUrlFile
+	return nil
+}
 
-			if err = os.WriteFile(myConfigFile, []byte(conf.String()), os.ModePerm); err != nil {
-				return err
-			}
+// reportSpecErrors prints any problems found while building the spec model, and reports whether it's still usable.
+// Circular references only matter to payload generation, which stops following them, so they aren't fatal.
+func reportSpecErrors(version, specPath string, errs []error) bool {
+	usable := true
 
-			if mediaType == "" {
-				fmt.Printf("\nConversion complete! To use, run\n\tsiege -R %s\n", myConfigFile)
-			} else {
-				fmt.Printf("\nConversion complete! To use, run\n\tsiege -R %s -T '%s'\n", myConfigFile, mediaType)
-			}
+	for _, err := range errs {
+		if err == nil {
+			continue
 		}
 
-		fmt.Println("")
+		if refErr, isType := err.(*resolver.ResolvingError); isType && refErr.CircularReference != nil {
+			fmt.Printf("Found a circular reference in %s; generated payloads will stop following it\n\t%v\n", specPath, err)
+			continue
+		}
 
-		return nil
+		fmt.Printf("Could not load %s spec in %s\n%v\n", version, specPath, err)
+		usable = false
 	}
 
-	err := app.Run(os.Args)
-
-	if err != nil {
-		log.Fatal(err)
-	}
+	return usable
 }
 
 func prefixFilename(prefix, filename string) string {
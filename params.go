@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Pseudo-style used for Swagger's `tsv` collection format, which has no OpenAPI v3 equivalent
+const styleTabDelimited = "tabDelimited"
+
+// getParamStyle fills in the OpenAPI v3 defaults for a parameter's style and explode settings
+func getParamStyle(in, style string, explode *bool) (string, bool) {
+	if style == "" {
+		switch in {
+		case "query", "cookie", "formData":
+			style = "form"
+		default:
+			style = "simple"
+		}
+	}
+
+	if explode != nil {
+		return style, *explode
+	}
+
+	return style, style == "form"
+}
+
+// getV2ParamStyle translates a Swagger `collectionFormat` into the equivalent OpenAPI v3 style and explode settings
+func getV2ParamStyle(in, collectionFormat string) (string, bool) {
+	switch collectionFormat {
+	case "ssv":
+		return "spaceDelimited", false
+	case "tsv":
+		return styleTabDelimited, false
+	case "pipes":
+		return "pipeDelimited", false
+	case "multi":
+		return "form", true
+	}
+
+	if in == "path" || in == "header" {
+		return "simple", false
+	}
+
+	return "form", false
+}
+
+// serializePathParam renders a value for substitution into a path template, using the `simple`, `label`, or `matrix`
+// style
+func serializePathParam(name string, value interface{}, style string, explode bool) string {
+	var prefix, separator, explodedSeparator string
+
+	switch style {
+	case "label":
+		prefix, separator, explodedSeparator = ".", ",", "."
+	case "matrix":
+		prefix, separator, explodedSeparator = ";", ",", ";"
+	default:
+		separator, explodedSeparator = ",", ","
+	}
+
+	if list, isList := paramValueToList(value); isList {
+		switch {
+		case style == "matrix" && explode:
+			return prefix + strings.Join(mapSlice(list, func(item string) string {
+				return fmt.Sprintf("%s=%s", name, item)
+			}), explodedSeparator)
+		case style == "matrix":
+			return fmt.Sprintf("%s%s=%s", prefix, name, strings.Join(list, separator))
+		case explode:
+			return prefix + strings.Join(list, explodedSeparator)
+		default:
+			return prefix + strings.Join(list, separator)
+		}
+	}
+
+	if pairs, isObject := paramValueToPairs(value); isObject {
+		if explode {
+			return prefix + strings.Join(mapSlice(pairs, func(pair [2]string) string {
+				return fmt.Sprintf("%s=%s", pair[0], pair[1])
+			}), explodedSeparator)
+		}
+
+		flattened := strings.Join(flattenPairs(pairs), separator)
+		if style == "matrix" {
+			return fmt.Sprintf("%s%s=%s", prefix, name, flattened)
+		}
+
+		return prefix + flattened
+	}
+
+	if style == "matrix" {
+		return fmt.Sprintf("%s%s=%s", prefix, name, renderParamValue(value))
+	}
+
+	return prefix + renderParamValue(value)
+}
+
+// serializeQueryParam renders a value into query (or form) fields, using the `form`, `spaceDelimited`,
+// `pipeDelimited`, or `deepObject` style
+func serializeQueryParam(name string, value interface{}, style string, explode bool) url.Values {
+	values := make(url.Values)
+
+	separator := ","
+	switch style {
+	case "spaceDelimited":
+		separator = " "
+	case "pipeDelimited":
+		separator = "|"
+	case styleTabDelimited:
+		separator = "\t"
+	}
+
+	if list, isList := paramValueToList(value); isList {
+		if explode {
+			values[name] = list
+		} else {
+			values.Add(name, strings.Join(list, separator))
+		}
+
+		return values
+	}
+
+	if pairs, isObject := paramValueToPairs(value); isObject {
+		switch {
+		case style == "deepObject":
+			addDeepObjectPairs(values, name, value)
+		case explode:
+			for _, pair := range pairs {
+				values.Add(pair[0], pair[1])
+			}
+		default:
+			values.Add(name, strings.Join(flattenPairs(pairs), separator))
+		}
+
+		return values
+	}
+
+	values.Add(name, renderParamValue(value))
+
+	return values
+}
+
+// serializeCookieParam renders a value into one or more cookies, using the `form` style
+func serializeCookieParam(name string, value interface{}, explode bool) []*http.Cookie {
+	cookies := make([]*http.Cookie, 0)
+
+	for key, list := range serializeQueryParam(name, value, "form", explode) {
+		for _, item := range list {
+			cookies = append(cookies, &http.Cookie{Name: key, Value: item})
+		}
+	}
+
+	sort.SliceStable(cookies, func(i, j int) bool {
+		return cookies[i].Name < cookies[j].Name
+	})
+
+	return cookies
+}
+
+// renderParamValue converts a primitive value into its string form, as used in paths, queries, and cookies
+func renderParamValue(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case bool:
+		return strconv.FormatBool(typed)
+	case float32:
+		return strconv.FormatFloat(float64(typed), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case json.Number:
+		return typed.String()
+	case fmt.Stringer:
+		return typed.String()
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		encoded, err := json.Marshal(value)
+		if err == nil {
+			return string(encoded)
+		}
+	}
+
+	return fmt.Sprint(value)
+}
+
+func paramValueToList(value interface{}) ([]string, bool) {
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Slice && reflected.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	// Byte slices are binary strings, not lists
+	if reflected.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+
+	list := make([]string, 0, reflected.Len())
+	for idx := 0; idx < reflected.Len(); idx++ {
+		list = append(list, renderParamValue(reflected.Index(idx).Interface()))
+	}
+
+	return list, true
+}
+
+func paramValueToPairs(value interface{}) ([][2]string, bool) {
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Map {
+		return nil, false
+	}
+
+	pairs := make([][2]string, 0, reflected.Len())
+	for _, key := range reflected.MapKeys() {
+		pairs = append(pairs, [2]string{renderParamValue(key.Interface()), renderParamValue(reflected.MapIndex(key).Interface())})
+	}
+
+	// Map iteration order is random; keep the output stable between invocations
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0]
+	})
+
+	return pairs, true
+}
+
+func addDeepObjectPairs(values url.Values, name string, value interface{}) {
+	if list, isList := paramValueToList(value); isList {
+		values[name] = append(values[name], list...)
+		return
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Map {
+		values.Add(name, renderParamValue(value))
+		return
+	}
+
+	for _, key := range reflected.MapKeys() {
+		addDeepObjectPairs(values, fmt.Sprintf("%s[%s]", name, renderParamValue(key.Interface())), reflected.MapIndex(key).Interface())
+	}
+}
+
+func flattenPairs(pairs [][2]string) []string {
+	flattened := make([]string, 0, len(pairs)*2)
+	for _, pair := range pairs {
+		flattened = append(flattened, pair[0], pair[1])
+	}
+
+	return flattened
+}
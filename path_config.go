@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The prefix marking a path key as a regular expression rather than a path or glob
+const pathRegexPrefix = "~"
+
+// The key configuring every method of a path at once
+const pathAnyMethod = "*"
+
+// The methods a path item can hold, in the order operations are converted
+var pathMethods = []string{"get", "post", "delete", "patch", "put", "head", "options"}
+
+// Ranks of path key, from least to most specific
+const (
+	pathKeyRegex = iota
+	pathKeyGlob
+	pathKeyExact
+)
+
+// pathKeyMatch is a path key matching a path, and how specifically it does so
+type pathKeyMatch struct {
+	Key  string
+	Rank int
+}
+
+// getPathConfig works out the configuration for each method of a path, combining everything which applies to it. From
+// least to most specific, that's the `params` defaults, regular expression keys (`paths."~^/users/.*$"`), glob keys
+// (`paths."/users/*"`), and finally the path itself; longer patterns of the same kind count as more specific. Within
+// each path key, the `*` method comes before the method itself. More specific settings replace less specific ones
+// param by param (and payload by payload, and so on). The path only counts as configured if something applies to it,
+// or everything is filled in automatically.
+func getPathConfig(pathsConfig PathsConfig, defaults ParamsConfig, rawPath string, auto bool) (PathConfig, bool, error) {
+	matches, err := matchPathKeys(pathsConfig, rawPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	pathConfig := make(PathConfig)
+
+	for _, method := range pathMethods {
+		config := PathMethodConfig{
+			Params:   make(map[string]ParamConfig),
+			Payloads: make(map[string]PayloadConfig),
+			Files:    make(map[string]string),
+			Fields:   make(map[string]ParamConfig),
+		}
+		configured := auto || len(defaults) > 0
+
+		for name, value := range defaults {
+			config.Params[name] = value
+		}
+
+		for _, match := range matches {
+			for _, methodKey := range []string{pathAnyMethod, method} {
+				methodConfig, exists := pathsConfig[match.Key][methodKey]
+				if !exists {
+					continue
+				}
+
+				config.merge(methodConfig)
+				configured = true
+			}
+		}
+
+		if configured {
+			pathConfig[method] = config
+		}
+	}
+
+	return pathConfig, auto || len(matches) > 0 || len(defaults) > 0, nil
+}
+
+// matchPathKeys finds the path keys matching a path, ordered from least to most specific
+func matchPathKeys(pathsConfig PathsConfig, rawPath string) ([]pathKeyMatch, error) {
+	matches := make([]pathKeyMatch, 0)
+
+	for key := range pathsConfig {
+		switch {
+		case key == rawPath:
+			matches = append(matches, pathKeyMatch{Key: key, Rank: pathKeyExact})
+		case strings.HasPrefix(key, pathRegexPrefix):
+			pattern, err := regexp.Compile(strings.TrimPrefix(key, pathRegexPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("Could not parse the path pattern `%s`\n\t%v\n\tCheck your configuration for `paths.%s`\n", key, err, key)
+			}
+
+			if pattern.MatchString(rawPath) {
+				matches = append(matches, pathKeyMatch{Key: key, Rank: pathKeyRegex})
+			}
+		case strings.ContainsAny(key, "*?"):
+			if globToRegexp(key).MatchString(rawPath) {
+				matches = append(matches, pathKeyMatch{Key: key, Rank: pathKeyGlob})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Rank != matches[j].Rank {
+			return matches[i].Rank < matches[j].Rank
+		}
+
+		if len(matches[i].Key) != len(matches[j].Key) {
+			return len(matches[i].Key) < len(matches[j].Key)
+		}
+
+		return matches[i].Key < matches[j].Key
+	})
+
+	return matches, nil
+}
+
+// globToRegexp converts a path glob into a regular expression matching whole paths: `*` matches within a single path
+// segment, `**` matches across any number of them, and `?` matches a single character other than `/`
+func globToRegexp(glob string) *regexp.Regexp {
+	pattern := new(strings.Builder)
+	pattern.WriteString("^")
+
+	for idx := 0; idx < len(glob); idx++ {
+		switch {
+		case strings.HasPrefix(glob[idx:], "**"):
+			pattern.WriteString(".*")
+			idx++
+		case glob[idx] == '*':
+			pattern.WriteString("[^/]*")
+		case glob[idx] == '?':
+			pattern.WriteString("[^/]")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(glob[idx : idx+1]))
+		}
+	}
+
+	pattern.WriteString("$")
+
+	return regexp.MustCompile(pattern.String())
+}
+
+// merge overlays another method configuration onto this one
+func (c PathMethodConfig) merge(other PathMethodConfig) {
+	for name, value := range other.Params {
+		c.Params[name] = value
+	}
+
+	for mediaType, value := range other.Payloads {
+		c.Payloads[mediaType] = value
+	}
+
+	for name, value := range other.Files {
+		c.Files[name] = value
+	}
+
+	for name, value := range other.Fields {
+		c.Fields[name] = value
+	}
+}
+
+// resolveFilePaths makes the relative data and payload file paths in these params relative to the given directory
+func (c ParamsConfig) resolveFilePaths(dir string) {
+	for name, value := range c {
+		value.From = resolveFilePath(dir, value.From)
+		c[name] = value
+	}
+}
+
+// resolveFilePaths makes the relative data, payload, and upload file paths in every path's configuration relative to
+// the given directory
+func (c PathsConfig) resolveFilePaths(dir string) {
+	for _, pathConfig := range c {
+		for _, methodConfig := range pathConfig {
+			ParamsConfig(methodConfig.Params).resolveFilePaths(dir)
+			ParamsConfig(methodConfig.Fields).resolveFilePaths(dir)
+
+			for mediaType, value := range methodConfig.Payloads {
+				value.File = resolveFilePath(dir, value.File)
+				methodConfig.Payloads[mediaType] = value
+			}
+
+			for name, value := range methodConfig.Files {
+				methodConfig.Files[name] = resolveFilePath(dir, value)
+			}
+		}
+	}
+}
+
+// resolveFilePaths makes the relative OAuth2 token script paths relative to the given directory
+func (c AuthConfig) resolveFilePaths(dir string) {
+	for _, creds := range c {
+		if script, exists := creds["script"]; exists {
+			creds["script"] = resolveFilePath(dir, script)
+		}
+	}
+}
+
+func resolveFilePath(dir, file string) string {
+	if file == "" || filepath.IsAbs(file) {
+		return file
+	}
+
+	return filepath.Join(dir, file)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// writePayloadFile saves a request body into the `siege.payloads` directory, and returns the payload to use in the
+// URL file: Siege's `<file` syntax, which sends the file's contents. Files are named for their contents, so identical
+// bodies share a single file.
+func writePayloadFile(c *cli.Context, content []byte, extension string) (string, error) {
+	dir := c.Path("siege.payloads")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("Could not create the payload directory %s\n\t%v\n", dir, err)
+	}
+
+	hash := sha256.Sum256(content)
+	file := path.Join(dir, hex.EncodeToString(hash[:8])+extension)
+
+	if err := os.WriteFile(file, content, os.ModePerm); err != nil {
+		return "", fmt.Errorf("Could not write the payload file %s\n\t%v\n", file, err)
+	}
+
+	return "<" + file, nil
+}
+
+// getURLFilePayload moves a payload into a payload file when it can't, or shouldn't, be written into the URL file
+// directly: URL files hold one request per line, Siege reads payloads starting with `<` as file names, and payloads
+// over `siege.inlineLimit` bytes make URL files unwieldy
+func getURLFilePayload(c *cli.Context, mediatype, payload string) (string, error) {
+	limit := c.Int("siege.inlineLimit")
+	tooLarge := limit > 0 && len(payload) > limit
+
+	if !tooLarge && !strings.ContainsAny(payload, "\r\n") && !strings.HasPrefix(payload, "<") {
+		return payload, nil
+	}
+
+	return writePayloadFile(c, []byte(payload), "."+mediaTypeFilePrefix(mediatype))
+}
+
+// getConfiguredPayload turns a configured payload into one for the URL file. Payload files must exist when the URL
+// file is generated, rather than when Siege gets around to reading them; inline payloads starting with `<` are
+// already Siege payload file references, so they're left alone.
+func getConfiguredPayload(c *cli.Context, mediatype string, config PayloadConfig) (string, error) {
+	if config.File == "" {
+		if strings.HasPrefix(config.Value, "<") {
+			return config.Value, nil
+		}
+
+		return getURLFilePayload(c, mediatype, config.Value)
+	}
+
+	info, err := os.Stat(config.File)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", config.File)
+	}
+
+	file, err := os.Open(config.File)
+	if err != nil {
+		return "", err
+	}
+	file.Close()
+
+	return "<" + config.File, nil
+}
@@ -2,22 +2,231 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/index"
+	"github.com/pb33f/libopenapi/utils"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
-func createFakePayload(schemaProxy *base.SchemaProxy) (interface{}, error) {
+// Which object properties generated values include
+const (
+	propertiesAll      = "all"
+	propertiesRequired = "required"
+)
+
+// payloadGenerator builds fake values from schemas. Each variant produces a different (but equally valid) value, so
+// arrays can be filled with distinct items, and enums can be cycled through.
+type payloadGenerator struct {
+	variant    int
+	enums      string
+	random     *rand.Rand
+	index      *index.SpecIndex
+	maxDepth   int
+	properties string
+	branches   string
+	examples   string
+
+	// Whether to generate values for everything left unconfigured (`--auto`), rather than asking for them
+	auto bool
+
+	// How many rows of each data file to use, and how to pick them, along with the files already read; the map is
+	// shared between copies of the generator
+	dataLimit  int
+	dataSample string
+	dataSets   map[string]*dataSet
+
+	// How many variants pass before the next set of examples changes, when taking their cross product
+	exampleStride int
+
+	// Which item of an array (or entry of a map) is being generated, so `first` enum selection can still keep them
+	// distinct
+	item int
+
+	// How deep the current value is, and the chain of schema references leading to it, to detect cycles
+	depth int
+	refs  []string
+
+	// Shared between copies of the generator, so the largest enum (or set of branches) seen decides how many
+	// variants to produce
+	variants *int
+}
+
+func newPayloadGenerator(c *cli.Context, idx *index.SpecIndex) (*payloadGenerator, error) {
+	enums := c.String("generate.enums")
+	if !slices.Contains([]string{enumsFirst, enumsRandom, enumsRoundRobin}, enums) {
+		return nil, fmt.Errorf("Unknown enum selection `%s`\n\tCheck your configuration for `generate.enums`; it should be `%s`, `%s`, or `%s`\n", enums, enumsFirst, enumsRandom, enumsRoundRobin)
+	}
+
+	properties := c.String("generate.properties")
+	if properties != propertiesAll && properties != propertiesRequired {
+		return nil, fmt.Errorf("Unknown property selection `%s`\n\tCheck your configuration for `generate.properties`; it should be `%s` or `%s`\n", properties, propertiesAll, propertiesRequired)
+	}
+
+	branches := c.String("generate.branches")
+	if branches != branchesFirst && branches != branchesAll {
+		return nil, fmt.Errorf("Unknown branch selection `%s`\n\tCheck your configuration for `generate.branches`; it should be `%s` or `%s`\n", branches, branchesFirst, branchesAll)
+	}
+
+	examples := c.String("generate.examples")
+	if !slices.Contains([]string{examplesFirst, examplesZip, examplesProduct}, examples) {
+		return nil, fmt.Errorf("Unknown example combination `%s`\n\tCheck your configuration for `generate.examples`; it should be `%s`, `%s`, or `%s`\n", examples, examplesFirst, examplesZip, examplesProduct)
+	}
+
+	dataSample := c.String("generate.dataSample")
+	if !slices.Contains([]string{sampleFirst, sampleEven, sampleRandom}, dataSample) {
+		return nil, fmt.Errorf("Unknown data sampling `%s`\n\tCheck your configuration for `generate.dataSample`; it should be `%s`, `%s`, or `%s`\n", dataSample, sampleFirst, sampleEven, sampleRandom)
+	}
+
+	maxDepth := c.Int("generate.maxDepth")
+	if maxDepth < 1 {
+		return nil, fmt.Errorf("Invalid maximum depth %d\n\tCheck your configuration for `generate.maxDepth`; it should be at least 1\n", maxDepth)
+	}
+
+	return &payloadGenerator{
+		enums:      enums,
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		index:      idx,
+		maxDepth:   maxDepth,
+		properties: properties,
+		branches:   branches,
+		examples:   examples,
+		auto:       c.Bool("auto"),
+		dataLimit:  c.Int("generate.dataLimit"),
+		dataSample: dataSample,
+		dataSets:   make(map[string]*dataSet),
+	}, nil
+}
+
+// eachVariant calls generate once per member of the largest set it finds to cycle through: round-robin enums, every
+// oneOf/anyOf branch, combined named examples, or rows from data files. Without any of those, it's called just once.
+func (g *payloadGenerator) eachVariant(generate func(gen *payloadGenerator) error) error {
+	variants := 1
+
+	for variant := 0; variant < variants; variant++ {
+		gen := g.withVariant(variant)
+		gen.variants = &variants
+
+		if err := generate(gen); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// errRecursionLimit means a value couldn't be generated without following a circular reference, or going deeper
+// than `generate.maxDepth`
+var errRecursionLimit = errors.New("recursion limit reached")
+
+// describeSchema names a schema for use in messages
+func describeSchema(schema *base.Schema) string {
+	if schema.Title != "" {
+		return schema.Title
+	}
+
+	if schema.ParentProxy != nil && schema.ParentProxy.GoLow().IsSchemaReference() {
+		return schema.ParentProxy.GoLow().GetSchemaReference()
+	}
+
+	return "an inline schema"
+}
+
+// expand asks eachVariant to produce at least this many variants
+func (g *payloadGenerator) expand(count int) {
+	if g.variants != nil && count > *g.variants {
+		*g.variants = count
+	}
+}
+
+// withVariant returns a copy of the generator producing the given variant
+func (g *payloadGenerator) withVariant(variant int) *payloadGenerator {
+	copied := *g
+	copied.variant = variant
+	copied.exampleStride = 1
+	copied.item = 0
+
+	return &copied
+}
+
+// withItem returns a copy of the generator producing the given variant, for the item of an array (or entry of a map)
+// that many variants past the current one
+func (g *payloadGenerator) withItem(variant int) *payloadGenerator {
+	copied := g.withVariant(variant)
+	copied.item = variant - g.variant
+
+	return copied
+}
+
+// descend returns a copy of the generator one level deeper, inside the given schema, unless that would follow a
+// circular reference or exceed the maximum depth
+func (g *payloadGenerator) descend(schemaProxy *base.SchemaProxy) (*payloadGenerator, bool) {
+	ref := ""
+	if schemaProxy.GoLow().IsSchemaReference() {
+		ref = schemaProxy.GoLow().GetSchemaReference()
+	}
+
+	if g.depth >= g.maxDepth || (ref != "" && slices.Contains(g.refs, ref)) {
+		return nil, false
+	}
+
+	copied := *g
+	copied.depth++
+	if ref != "" {
+		copied.refs = append(slices.Clip(g.refs), ref)
+	}
+
+	return &copied, true
+}
+
+// truncatedValue finds the simplest value a schema allows without generating anything inside it: null, an empty
+// array, or an empty object
+func truncatedValue(schema *base.Schema) (interface{}, bool) {
+	switch {
+	case (schema.Nullable != nil && *schema.Nullable) || slices.Contains(schema.Type, "null"):
+		return nil, true
+	case slices.Contains(schema.Type, "array") && (schema.MinItems == nil || *schema.MinItems < 1):
+		return []interface{}{}, true
+	case slices.Contains(schema.Type, "object") && len(schema.Required) < 1 && (schema.MinProperties == nil || *schema.MinProperties < 1):
+		return map[string]interface{}{}, true
+	default:
+		return nil, false
+	}
+}
+
+func (g *payloadGenerator) createFakePayload(schemaProxy *base.SchemaProxy) (interface{}, error) {
 	var output interface{}
 
+	gen, canDescend := g.descend(schemaProxy)
+	if !canDescend {
+		schema, err := schemaProxy.BuildSchema()
+		if err != nil {
+			return nil, err
+		}
+
+		if value, allowed := truncatedValue(schema); allowed {
+			return value, nil
+		}
+
+		return nil, fmt.Errorf("Couldn't generate a value for %s without following it in circles, or going deeper than `generate.maxDepth` (%d)\n\t%w", describeSchema(schema), g.maxDepth, errRecursionLimit)
+	}
+
 	for i := 0; i < 5; i++ {
 		schemaOption, err := schemaProxy.BuildSchema()
 		if err != nil {
 			return nil, err
 		}
 
-		output, err = createFakePayloadFromSchema(schemaOption)
+		output, err = gen.createFakePayloadFromSchema(schemaOption)
 		if err == nil {
 			break
 		}
@@ -33,13 +242,17 @@ func createFakePayload(schemaProxy *base.SchemaProxy) (interface{}, error) {
 	return output, nil
 }
 
-func createFakePayloadFromSchema(schema *base.Schema) (interface{}, error) {
+func (g *payloadGenerator) createFakePayloadFromSchema(schema *base.Schema) (interface{}, error) {
 	// schemaJson, err := json.Marshal(schema)
 	// if err != nil {
 	// 	return nil, fmt.Errorf("Couldn't JSON-encode schema %v\n\t%s", schema, err)
 	// }
 	// fmt.Printf("Processing schema: %s", schemaJson)
 
+	if value, exists := g.pickEnumValue(schema); exists {
+		return value, nil
+	}
+
 	if schema.Default != nil {
 		return schema.Default, nil
 	}
@@ -53,20 +266,25 @@ func createFakePayloadFromSchema(schema *base.Schema) (interface{}, error) {
 	}
 
 	if len(schema.OneOf) > 0 {
-		return createFakePayload(schema.OneOf[0])
+		return g.createFakeBranch(schema, schema.OneOf)
 	}
 
 	if len(schema.AnyOf) > 0 {
-		return createFakePayload(schema.AnyOf[0])
+		return g.createFakeBranch(schema, schema.AnyOf)
 	}
 
 	payload := make(map[string]interface{})
 	for _, schemaProxy := range schema.AllOf {
-		partialPayload, err := createFakePayload(schemaProxy)
+		partialPayload, err := g.createFakePayload(schemaProxy)
 		if err != nil {
 			return nil, err
 		}
 
+		if partialPayload == nil {
+			// A nullable schema cut short by the recursion limit
+			continue
+		}
+
 		partialMap, isType := partialPayload.(map[string]interface{})
 		if !isType {
 			return nil, fmt.Errorf("Couldn't generate payload; expected `%T`, but got `%T`", payload, partialPayload)
@@ -75,6 +293,11 @@ func createFakePayloadFromSchema(schema *base.Schema) (interface{}, error) {
 		for key, val := range partialMap {
 			payload[key] = val
 		}
+
+		// A parent schema's discriminator identifies its children by their own names
+		if parent, err := schemaProxy.BuildSchema(); err == nil && parent.Discriminator != nil && schema.ParentProxy != nil {
+			tagDiscriminator(parent.Discriminator, schema.ParentProxy, payload)
+		}
 	}
 
 	if len(payload) > 0 {
@@ -84,51 +307,71 @@ func createFakePayloadFromSchema(schema *base.Schema) (interface{}, error) {
 	for _, schemaType := range schema.Type {
 		switch schemaType {
 		case "object":
-			return iterateObject(schema)
+			return g.iterateObject(schema)
 		case "array":
-			return iterateArray(schema)
+			return g.iterateArray(schema)
 		case "null":
 			return nil, nil
 		case "boolean":
-			return true, nil
+			return g.variant%2 == 0, nil
 		case "number":
+			number, err := createFakeNumber(schema, false, g.variant)
+			if err != nil {
+				return nil, err
+			}
+
 			switch schema.Format {
 			case "float":
-				return float32(0), nil
+				return float32(number), nil
 			case "double":
-				return float64(0), nil
+				return float64(number), nil
 			default:
-				return float64(0), nil
+				return float64(number), nil
 			}
 		case "integer":
+			number, err := createFakeNumber(schema, true, g.variant)
+			if err != nil {
+				return nil, err
+			}
+
 			switch schema.Format {
 			case "int32":
-				return int32(0), nil
+				return int32(number), nil
 			case "int64":
-				return int64(0), nil
+				return int64(number), nil
 			default:
-				return int(0), nil
+				return int(number), nil
 			}
 		case "string":
-			return "test", nil
+			return createFakeString(schema, g.variant)
 		default:
 			return nil, fmt.Errorf("Unknown type %s; can't generate a fake value for something we don't understand\n\tPlease let us know you'd like it added!", schemaType)
 		}
 	}
 
 	switch {
-	case len(schema.Properties) > 0:
-		return iterateObject(schema)
+	case len(schema.Properties) > 0, len(schema.PatternProperties) > 0, schema.AdditionalProperties != nil:
+		return g.iterateObject(schema)
 	case schema.Items != nil:
-		return iterateArray(schema)
+		return g.iterateArray(schema)
 	default:
 		return nil, fmt.Errorf("try-rebuild")
 	}
 }
 
-func getPayloadFromType(mediatype string, data interface{}) (string, error) {
-	switch mediatype {
-	case "application/json":
+// getPayloadFromType serializes a value as the given media type. Encoding settings only apply to form bodies, and
+// may be nil.
+func getPayloadFromType(mediatype string, data interface{}, encoding map[string]*v3.Encoding) (string, error) {
+	switch {
+	case isXMLMediaType(mediatype):
+		return encodeXMLPayload(data, nil)
+	case isFormMediaType(mediatype):
+		return encodeFormPayload(data, encoding)
+	case isNDJSONMediaType(mediatype):
+		return encodeNDJSONPayload(data)
+	case isTextMediaType(mediatype):
+		return encodeTextPayload(data)
+	case isJSONMediaType(mediatype):
 		// don't serialize a string that's already serialized
 		if stringData, isType := data.(string); isType && (strings.Contains(stringData, "{") || strings.Contains(stringData, "[")) {
 			return stringData, nil
@@ -142,11 +385,90 @@ func getPayloadFromType(mediatype string, data interface{}) (string, error) {
 	}
 }
 
-func iterateObject(schema *base.Schema) (interface{}, error) {
+// encodeNDJSONPayload serializes each item of an array as its own line of JSON; anything else is a single line
+func encodeNDJSONPayload(data interface{}) (string, error) {
+	// don't serialize a string that's already serialized
+	if stringData, isType := data.(string); isType && (strings.Contains(stringData, "{") || strings.Contains(stringData, "[")) {
+		return stringData, nil
+	}
+
+	list, isList := data.([]interface{})
+	if !isList {
+		list = []interface{}{data}
+	}
+
+	lines := make([]string, 0, len(list))
+	for _, item := range list {
+		bytes, err := json.Marshal(item)
+		if err != nil {
+			return "", err
+		}
+
+		lines = append(lines, string(bytes))
+	}
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// encodeTextPayload sends strings and other simple values as they are; there's no plain text form for anything else
+func encodeTextPayload(data interface{}) (string, error) {
+	if isComplexPayload(data) {
+		return "", fmt.Errorf("Can't encode a %T as plain text\n", data)
+	}
+
+	return renderParamValue(data), nil
+}
+
+// iterateObject generates the schema's properties, leaving out read-only ones (and optional ones, when only
+// required properties are wanted, unless minProperties needs them). Write-only properties are included, since
+// they're meant for requests. Map-like schemas then get sample entries from addAdditionalProperties.
+func (g *payloadGenerator) iterateObject(schema *base.Schema) (interface{}, error) {
 	output := make(map[string]interface{})
 
-	for k, v := range schema.Properties {
-		payload, err := createFakePayload(v)
+	minProperties, maxProperties := int64(0), int64(-1)
+	if schema.MinProperties != nil {
+		minProperties = *schema.MinProperties
+	}
+	if schema.MaxProperties != nil {
+		maxProperties = *schema.MaxProperties
+	}
+
+	// Required properties come first, so maxProperties only ever cuts optional ones
+	names := maps.Keys(schema.Properties)
+	sort.SliceStable(names, func(i, j int) bool {
+		iRequired, jRequired := slices.Contains(schema.Required, names[i]), slices.Contains(schema.Required, names[j])
+		if iRequired != jRequired {
+			return iRequired
+		}
+
+		return names[i] < names[j]
+	})
+
+	for _, k := range names {
+		v := schema.Properties[k]
+
+		required := slices.Contains(schema.Required, k)
+		if !required && g.properties == propertiesRequired && int64(len(output)) >= minProperties {
+			continue
+		}
+		if !required && maxProperties >= 0 && int64(len(output)) >= maxProperties {
+			continue
+		}
+
+		// Generated values are only ever sent, so servers would reject (or ignore) read-only properties
+		if propertySchema, err := v.BuildSchema(); err == nil && propertySchema.ReadOnly {
+			continue
+		}
+
+		// Optional properties which would recurse are simply left out
+		if _, canDescend := g.descend(v); !canDescend && !required {
+			continue
+		}
+
+		payload, err := g.createFakePayload(v)
+		if errors.Is(err, errRecursionLimit) && !required {
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -154,22 +476,217 @@ func iterateObject(schema *base.Schema) (interface{}, error) {
 		output[k] = payload
 	}
 
+	if err := g.addAdditionalProperties(schema, output, minProperties, maxProperties); err != nil {
+		return nil, err
+	}
+
+	if int64(len(output)) < minProperties {
+		fmt.Printf("Couldn't generate the %d properties %s needs; your tests may not work as expected\n", minProperties, describeSchema(schema))
+	}
+
 	return output, nil
 }
 
-func iterateArray(schema *base.Schema) (interface{}, error) {
+// addAdditionalProperties adds sample entries to map-like objects, using patternProperties and additionalProperties
+// for the values, and patternProperties or propertyNames for the keys. Objects without any declared properties get
+// at least one entry; others only get enough to satisfy minProperties.
+func (g *payloadGenerator) addAdditionalProperties(schema *base.Schema, output map[string]interface{}, minProperties, maxProperties int64) error {
+	target := minProperties
+	if len(schema.Properties) < 1 && target < 1 {
+		target = 1
+	}
+	if maxProperties >= 0 && target > maxProperties {
+		target = maxProperties
+	}
+
+	patterns := maps.Keys(schema.PatternProperties)
+	sort.Strings(patterns)
+
+	additional, additionalSchema := schema.AdditionalProperties.(*base.SchemaProxy)
+	freeForm, _ := schema.AdditionalProperties.(bool)
+	if schema.AdditionalProperties == nil {
+		// Objects are open by default, but only fill them in when minProperties demands it
+		freeForm = int64(len(output)) < minProperties
+	}
+
+	sources := len(patterns)
+	if additionalSchema || freeForm {
+		sources++
+	}
+	if sources < 1 {
+		return nil
+	}
+
+	for attempt := 0; int64(len(output)) < target && attempt < int(target)*4; attempt++ {
+		variant := g.variant + attempt
+		source := attempt % sources
+
+		var key string
+		var err error
+		var valueSchema *base.SchemaProxy
+
+		if source < len(patterns) {
+			key, err = createFakeStringFromPattern(patterns[source], 1, -1, variant)
+			if err != nil {
+				fmt.Printf("Couldn't generate a property name matching the pattern `%s`\n\t%v\n", patterns[source], err)
+				continue
+			}
+
+			valueSchema = schema.PatternProperties[patterns[source]]
+		} else {
+			key, err = g.createFakePropertyName(schema, variant)
+			if err != nil {
+				return err
+			}
+
+			valueSchema = additional
+		}
+
+		if _, exists := output[key]; exists {
+			continue
+		}
+
+		if valueSchema == nil {
+			// Anything goes, so keep it simple
+			output[key] = "test" + fakeStringSuffix(variant)
+			continue
+		}
+
+		value, err := g.withItem(variant).createFakePayload(valueSchema)
+		if errors.Is(err, errRecursionLimit) && int64(len(output)) >= minProperties {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		output[key] = value
+	}
+
+	return nil
+}
+
+// createFakePropertyName generates a key for a map-like object, satisfying its propertyNames schema if it has one
+func (g *payloadGenerator) createFakePropertyName(schema *base.Schema, variant int) (string, error) {
+	if schema.PropertyNames == nil {
+		return "key" + fakeStringSuffix(variant), nil
+	}
+
+	nameSchema, err := schema.PropertyNames.BuildSchema()
+	if err != nil {
+		return "", err
+	}
+
+	if name, exists := g.withItem(variant).pickEnumValue(nameSchema); exists {
+		return renderParamValue(name), nil
+	}
+
+	// Property names are always strings, so the schema doesn't need to say so
+	return createFakeString(nameSchema, variant)
+}
+
+// iterateArray generates as many distinct items as the schema's minItems requires (at least one, unless maxItems
+// forbids it). When the item schema doesn't allow enough distinct values, items are repeated, unless uniqueItems
+// forbids that too.
+func (g *payloadGenerator) iterateArray(schema *base.Schema) (interface{}, error) {
+	count := int64(1)
+	if schema.MinItems != nil && *schema.MinItems > count {
+		count = *schema.MinItems
+	}
+	if schema.MaxItems != nil && *schema.MaxItems < count {
+		count = *schema.MaxItems
+	}
+
+	if schema.MaxItems != nil && schema.MinItems != nil && *schema.MaxItems < *schema.MinItems {
+		return nil, fmt.Errorf("Can't generate an array for %s; maxItems %d is less than minItems %d\n", describeSchema(schema), *schema.MaxItems, *schema.MinItems)
+	}
+
+	if schema.Items == nil {
+		// Without an items schema, anything goes; use the variant numbers themselves
+		output := make([]interface{}, 0, count)
+		for idx := 0; idx < int(count); idx++ {
+			output = append(output, g.variant+idx+1)
+		}
+
+		return output, nil
+	}
+
 	if schema.Items.IsB() {
-		if schema.Items.B {
+		if schema.Items.B || count < 1 {
 			return []interface{}{}, nil
 		}
 
-		return nil, fmt.Errorf("Can't determine how to generate a value for %s (whose definition in the spec is `false`)\n", schema.Title)
+		return nil, fmt.Errorf("Can't determine how to generate a value for %s (whose definition in the spec is `false`)\n", describeSchema(schema))
 	}
 
-	payload, err := createFakePayload(schema.Items.A)
-	if err != nil {
-		return nil, err
+	// Items which would recurse are left out, if the array can be empty
+	if _, canDescend := g.descend(schema.Items.A); !canDescend && (schema.MinItems == nil || *schema.MinItems < 1) {
+		return []interface{}{}, nil
 	}
 
-	return []interface{}{payload}, nil
+	output := make([]interface{}, 0, count)
+	seen := make(map[string]bool)
+
+	// Some item schemas only allow a handful of values, so don't keep trying forever
+	for variant := g.variant; len(output) < int(count) && variant < g.variant+int(count)*4; variant++ {
+		payload, err := g.withItem(variant).createFakePayload(schema.Items.A)
+		if errors.Is(err, errRecursionLimit) && (schema.MinItems == nil || *schema.MinItems < 1) {
+			return []interface{}{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[string(key)] {
+			continue
+		}
+
+		seen[string(key)] = true
+		output = append(output, payload)
+	}
+
+	if len(output) < int(count) {
+		if len(output) < 1 {
+			return nil, fmt.Errorf("Couldn't generate any of the %d items %s needs\n", count, describeSchema(schema))
+		}
+
+		if g.hasUniqueItems(schema) {
+			return nil, fmt.Errorf("Couldn't generate %d distinct items for %s, which requires uniqueItems\n", count, describeSchema(schema))
+		}
+
+		fmt.Printf("Couldn't generate %d distinct items for %s; repeating items instead\n", count, describeSchema(schema))
+
+		for distinct := len(output); len(output) < int(count); {
+			output = append(output, output[len(output)%distinct])
+		}
+	}
+
+	return output, nil
+}
+
+// hasUniqueItems reports whether an array schema requires its items to be distinct. libopenapi declares uniqueItems as
+// an integer, so a boolean in the spec leaves it unset; read it from the spec directly in that case.
+func (g *payloadGenerator) hasUniqueItems(schema *base.Schema) bool {
+	if schema.UniqueItems != nil {
+		return *schema.UniqueItems != 0
+	}
+
+	node := g.schemaNode(schema)
+	if node == nil {
+		return false
+	}
+
+	_, uniqueNode := utils.FindKeyNodeTop("uniqueItems", node.Content)
+	if uniqueNode == nil {
+		return false
+	}
+
+	var unique bool
+
+	return uniqueNode.Decode(&unique) == nil && unique
 }
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+	"gopkg.in/yaml.v3"
+)
+
+// The output paths a profile prefixes with its name, unless it sets them itself
+var profileOutputFlags = []string{"siege.urls", "siege.config", "siege.cookies", "siege.payloads"}
+
+// newProfileSourceFromFlagFunc loads the configuration file, overlaid with the profile chosen by `--profile` (if any).
+// Profiles live under `profiles.{name}` in the configuration file, and can set anything the base configuration can;
+// tables are merged key by key, so a profile only needs the values that differ.
+func newProfileSourceFromFlagFunc(confFlag, profileFlag string) func(*cli.Context) (altsrc.InputSourceContext, error) {
+	return func(c *cli.Context) (altsrc.InputSourceContext, error) {
+		profile := c.String(profileFlag)
+		if profile == "" {
+			return altsrc.DetectNewSourceFromFlagFunc(confFlag)(c)
+		}
+
+		confPath := c.Path(confFlag)
+
+		base, err := readConfigMap(confPath)
+		if err != nil {
+			return nil, err
+		}
+
+		overlay, err := getProfileConfig(confPath, base, profile)
+		if err != nil {
+			return nil, err
+		}
+
+		delete(base, "profiles")
+
+		return altsrc.NewMapInputSource(confPath, toInputSourceMap(mergeConfigMaps(base, overlay))), nil
+	}
+}
+
+// readConfigMap reads a configuration file in any of the formats altsrc supports, chosen by its extension
+func readConfigMap(confPath string) (map[string]interface{}, error) {
+	raw, err := os.ReadFile(confPath)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read %s\n%v\n", confPath, err)
+	}
+
+	config := make(map[string]interface{})
+
+	switch filepath.Ext(confPath) {
+	case ".conf", ".toml":
+		err = toml.Unmarshal(raw, &config)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &config)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		err = decoder.Decode(&config)
+	default:
+		err = fmt.Errorf("Unknown configuration file type %s; use .conf, .toml, .yaml, .yml, or .json", filepath.Ext(confPath))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse %s\n%v\n", confPath, err)
+	}
+
+	return config, nil
+}
+
+// getProfileConfig finds a profile's settings in the configuration
+func getProfileConfig(confPath string, config map[string]interface{}, profile string) (map[string]interface{}, error) {
+	profiles, _ := config["profiles"].(map[string]interface{})
+
+	overlay, isMap := profiles[profile].(map[string]interface{})
+	if !isMap {
+		return nil, fmt.Errorf("Profile `%s` not found in %s\n\tCheck your configuration for `profiles.%s`\n", profile, confPath, profile)
+	}
+
+	return overlay, nil
+}
+
+// getProfileNames lists the profiles in a configuration file, in order by name
+func getProfileNames(confPath string) ([]string, error) {
+	config, err := readConfigMap(confPath)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, _ := config["profiles"].(map[string]interface{})
+	if len(profiles) < 1 {
+		return nil, fmt.Errorf("No profiles found in %s\n\tNeed at least one `profiles.{name}` table\n", confPath)
+	}
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// mergeConfigMaps overlays one configuration on another, merging tables present in both, and replacing anything else
+func mergeConfigMaps(base, overlay map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range overlay {
+		baseTable, baseIsTable := merged[key].(map[string]interface{})
+		overlayTable, overlayIsTable := value.(map[string]interface{})
+
+		if baseIsTable && overlayIsTable {
+			merged[key] = mergeConfigMaps(baseTable, overlayTable)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// toInputSourceMap converts a parsed configuration into the types altsrc expects from its own file loaders: tables keyed
+// by interface{}, and whole numbers as int
+func toInputSourceMap(config map[string]interface{}) map[interface{}]interface{} {
+	converted := make(map[interface{}]interface{}, len(config))
+	for key, value := range config {
+		converted[key] = toInputSourceValue(value)
+	}
+
+	return converted
+}
+
+func toInputSourceValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return toInputSourceMap(value)
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for idx, item := range value {
+			converted[idx] = toInputSourceValue(item)
+		}
+
+		return converted
+	case []map[string]interface{}:
+		converted := make([]interface{}, len(value))
+		for idx, item := range value {
+			converted[idx] = toInputSourceMap(item)
+		}
+
+		return converted
+	case int64:
+		return int(value)
+	case json.Number:
+		if number, err := value.Int64(); err == nil {
+			return int(number)
+		}
+
+		number, _ := value.Float64()
+
+		return number
+	default:
+		return value
+	}
+}
+
+// explicitOutputFlags notes which output paths were set on the command line, before the configuration file fills in the
+// rest
+func explicitOutputFlags(c *cli.Context) map[string]bool {
+	explicit := make(map[string]bool, len(profileOutputFlags))
+	for _, name := range profileOutputFlags {
+		explicit[name] = c.IsSet(name)
+	}
+
+	return explicit
+}
+
+// applyOutputPaths adjusts where the generated files go: a chosen profile prefixes each file name with its own (unless
+// the profile sets that path itself), and `--outdir` places everything in the given directory. Only the defaults and
+// relative paths from the configuration file are adjusted; paths set on the command line, and absolute paths in the
+// configuration file, are used as they are.
+func applyOutputPaths(c *cli.Context, explicit map[string]bool, hasConfig bool) error {
+	profile := c.String("profile")
+
+	var config, overlay map[string]interface{}
+
+	if hasConfig {
+		confPath := c.Path("conf")
+
+		var err error
+
+		config, err = readConfigMap(confPath)
+		if err != nil {
+			return err
+		}
+
+		if profile != "" {
+			overlay, err = getProfileConfig(confPath, config, profile)
+			if err != nil {
+				return err
+			}
+
+			delete(config, "profiles")
+			config = mergeConfigMaps(config, overlay)
+		}
+	}
+
+	outDir := c.Path("outdir")
+
+	for _, name := range profileOutputFlags {
+		if explicit[name] {
+			continue
+		}
+
+		value := c.Path(name)
+
+		// Paths from the configuration file have already been made absolute, so work from the path as it was written
+		configured, inConfig := getConfigValue(config, name)
+		rawValue, isString := configured.(string)
+		if inConfig && isString && filepath.IsAbs(rawValue) {
+			continue
+		}
+
+		if profile != "" && outDir == "" && !configHasKey(overlay, name) {
+			value = prefixFilename(profile, value)
+		}
+
+		if outDir != "" && inConfig && isString {
+			value = path.Join(outDir, rawValue)
+		} else if outDir != "" {
+			value = path.Join(outDir, value)
+		}
+
+		if err := c.Set(name, value); err != nil {
+			return err
+		}
+	}
+
+	if outDir != "" {
+		if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+			return fmt.Errorf("Could not create the output directory %s\n\t%v\n", outDir, err)
+		}
+	}
+
+	return nil
+}
+
+// configHasKey reports whether a configuration sets a dotted key, either as nested tables or a quoted key
+func configHasKey(config map[string]interface{}, key string) bool {
+	_, exists := getConfigValue(config, key)
+
+	return exists
+}
+
+// getConfigValue finds a dotted key in a configuration, either as nested tables or a quoted key
+func getConfigValue(config map[string]interface{}, key string) (interface{}, bool) {
+	if value, exists := config[key]; exists {
+		return value, true
+	}
+
+	parts := strings.SplitN(key, ".", 2)
+	table, isTable := config[parts[0]].(map[string]interface{})
+	if len(parts) < 2 || !isTable {
+		return nil, false
+	}
+
+	return getConfigValue(table, parts[1])
+}
+
+// generateAllProfiles runs the whole conversion once per profile, each with its own directory (named for the profile,
+// within `--outdir` if it's set). Every run gets a freshly built app, so nothing set by one profile leaks into another.
+func generateAllProfiles(c *cli.Context) error {
+	names, err := getProfileNames(c.Path("conf"))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		fmt.Printf("\nGenerating profile `%s`\n", name)
+
+		args := append(withoutProfileArgs(os.Args), "--profile", name, "--outdir", path.Join(c.Path("outdir"), name))
+		if err := newApp().Run(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withoutProfileArgs strips the profile selection and output directory from the command line, so each profile's run
+// can supply its own
+func withoutProfileArgs(args []string) []string {
+	stripped := make([]string, 0, len(args))
+
+	for idx := 0; idx < len(args); idx++ {
+		name := strings.TrimLeft(args[idx], "-")
+		if idx == 0 || name == args[idx] {
+			stripped = append(stripped, args[idx])
+			continue
+		}
+
+		switch name {
+		case "all-profiles":
+			continue
+		case "profile", "p", "outdir", "o":
+			idx++
+			continue
+		}
+
+		if strings.HasPrefix(name, "all-profiles=") || strings.HasPrefix(name, "profile=") || strings.HasPrefix(name, "p=") ||
+			strings.HasPrefix(name, "outdir=") || strings.HasPrefix(name, "o=") {
+			continue
+		}
+
+		stripped = append(stripped, args[idx])
+	}
+
+	return stripped
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/utils"
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+)
+
+// authGroup is a set of URLs sharing the same credentials, along with the Siege config supplying them.
+// Siege applies headers and logins to every URL in a run, so each group needs its own siege.conf and urls.txt.
+type authGroup struct {
+	Name   string
+	URLs   urlList
+	Config *SiegeConfig
+}
+
+type securitySchemeApplier func(name string, scopes []string, urls urlList, conf *SiegeConfig) error
+
+// getOperationSecurity returns the security requirements in effect for an operation: its own if it declares any (an
+// empty list opts out of security entirely), or the document-wide ones otherwise
+func getOperationSecurity(global, operation []*base.SecurityRequirement, operationNode *yaml.Node) []*base.SecurityRequirement {
+	if len(operation) > 0 {
+		return operation
+	}
+
+	// libopenapi doesn't distinguish between `security: []` and no security at all, so check the spec directly
+	if operationNode != nil {
+		if _, securityNode := utils.FindKeyNodeTop("security", operationNode.Content); securityNode != nil {
+			return []*base.SecurityRequirement{}
+		}
+	}
+
+	return global
+}
+
+// chooseSecurityRequirement picks the first alternative from a list of security requirements that can be satisfied
+// using the configured credentials
+func chooseSecurityRequirement(method, rawPath string, requirements []*base.SecurityRequirement, auth AuthConfig) (map[string][]string, error) {
+	if len(requirements) < 1 {
+		return nil, nil
+	}
+
+	for _, requirement := range requirements {
+		satisfied := true
+
+		for name := range requirement.Requirements {
+			if _, exists := auth[name]; !exists {
+				satisfied = false
+				break
+			}
+		}
+
+		if satisfied {
+			return requirement.Requirements, nil
+		}
+	}
+
+	alternatives := mapSlice(requirements, func(requirement *base.SecurityRequirement) string {
+		names := maps.Keys(requirement.Requirements)
+		sort.Strings(names)
+
+		return strings.Join(mapSlice(names, func(name string) string {
+			return fmt.Sprintf("`auth.%s.*`", name)
+		}), " and ")
+	})
+
+	return nil, fmt.Errorf("Auth not configured for %s %s\n\tNeed one of: %s\n", strings.ToUpper(method), rawPath, strings.Join(alternatives, ", or "))
+}
+
+// groupBySecurity splits URLs into groups sharing the same security requirement, and applies each group's security
+// schemes to a fresh Siege config
+func groupBySecurity(urls urlList, newConfig func() (*SiegeConfig, error), applyScheme securitySchemeApplier) ([]authGroup, error) {
+	groupUrls := make(map[string]urlList)
+	groupScopes := make(map[string]map[string][]string)
+
+	for _, data := range urls {
+		names := maps.Keys(data.Security)
+		sort.Strings(names)
+
+		key := strings.Join(names, "+")
+
+		groupUrls[key] = append(groupUrls[key], data)
+
+		if _, exists := groupScopes[key]; !exists {
+			groupScopes[key] = make(map[string][]string)
+		}
+
+		for name, scopes := range data.Security {
+			groupScopes[key][name] = uniqueSlice(append(groupScopes[key][name], scopes...))
+		}
+	}
+
+	keys := maps.Keys(groupUrls)
+	sort.Strings(keys)
+
+	groups := make([]authGroup, 0, len(keys))
+
+	for _, key := range keys {
+		conf, err := newConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		group := authGroup{
+			Name:   key,
+			URLs:   groupUrls[key],
+			Config: conf,
+		}
+
+		if group.Name == "" {
+			group.Name = "anonymous"
+		}
+
+		names := maps.Keys(groupScopes[key])
+		sort.Strings(names)
+
+		for _, name := range names {
+			if err := applyScheme(name, groupScopes[key][name], group.URLs, group.Config); err != nil {
+				return nil, err
+			}
+		}
+
+		groups = append(groups, group)
+	}
+
+	if len(groups) < 1 {
+		conf, err := newConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, authGroup{Name: "anonymous", URLs: urlList{}, Config: conf})
+	}
+
+	return groups, nil
+}
@@ -3,13 +3,23 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+	"golang.org/x/exp/slices"
 )
 
 type SiegeConfig struct {
 	Variables       SiegeVars     `siege:"-"`
+	VariableMode    string        `siege:"-"`
+	BaseURL         string        `siege:"-"`
+	CookieFile      string        `siege:"-"`
 	Verbose         SiegeBoolTF   `siege:"verbose"`
 	Color           SiegeBoolOO   `siege:"color"`
 	Quiet           SiegeBoolTF   `siege:"quiet"`
@@ -53,7 +63,7 @@ type SiegeConfig struct {
 	SslTimeout      int           `siege:"ssl-timeout,omitempty"`
 	SslCiphers      string        `siege:"ssl-ciphers,omitempty"`
 	ProxyHost       string        `siege:"proxy-host,omitempty"`
-	ProxyPort       int16         `siege:"proxy-port,omitempty"`
+	ProxyPort       int           `siege:"proxy-port,omitempty"`
 	ProxyLogin      SiegeCreds    `siege:"proxy-login,omitempty"`
 	FollowRedirects SiegeBoolTF   `siege:"follow-location"`
 	Headers         http.Header   `siege:"header,omitempty"`
@@ -99,7 +109,19 @@ func NewSiegeConfig() *SiegeConfig {
 func (c *SiegeConfig) String() string {
 	writer := new(strings.Builder)
 
-	writer.WriteString(c.Variables.String())
+	if c.CookieFile != "" {
+		// There's no setting to point Siege at a cookies file, so this is the best we can do
+		writer.WriteString("# Siege has no setting for its cookies file; it always reads $HOME/.siege/cookies.txt\n")
+		writer.WriteString(fmt.Sprintf("# Copy %s there (or merge it into the existing file) before running these URLs\n", c.CookieFile))
+	}
+
+	if c.VariableMode == variablesEnv {
+		for _, name := range c.EnvironmentVariables() {
+			writer.WriteString(fmt.Sprintf("# %s is read from the environment\n", name))
+		}
+	} else {
+		writer.WriteString(c.Variables.String())
+	}
 
 	reflected := reflect.ValueOf(*c)
 	for i := 0; i < reflected.Type().NumField(); i++ {
@@ -124,11 +146,17 @@ func (c *SiegeConfig) String() string {
 			for idx := 0; idx < fieldVal.Len(); idx++ {
 				writer.WriteString(fmt.Sprintf("%s = %s\n", name, fieldVal.Index(idx).String()))
 			}
-		case "int", "int16":
+		case "int":
 			writer.WriteString(fmt.Sprintf("%s = %d\n", name, fieldVal.Int()))
 		case "float64":
 			writer.WriteString(fmt.Sprintf("%s = %f\n", name, fieldVal.Float()))
-		case "SiegeBoolTF", "SiegeBoolOO", "SiegeCreds", "urlList", "Duration":
+		case "Duration":
+			writer.WriteString(fmt.Sprintf("%s = %dS\n", name, int64(time.Duration(fieldVal.Int()).Seconds())))
+		case "urlList":
+			for idx := 0; idx < fieldVal.Len(); idx++ {
+				writer.WriteString(fmt.Sprintf("%s = %s\n", name, fieldVal.Index(idx).Interface().(urlData).String()))
+			}
+		case "SiegeBoolTF", "SiegeBoolOO", "SiegeCreds":
 			result := fieldVal.MethodByName("String").Call([]reflect.Value{})
 			writer.WriteString(fmt.Sprintf("%s = %s\n", name, result[0].String()))
 		case "Header":
@@ -146,13 +174,347 @@ func (c *SiegeConfig) String() string {
 	return writer.String()
 }
 
-type SiegeVars map[string]string
+// Settings which openapi2siege manages itself, so users can't override them via `siege.settings`
+var managedSiegeSettings = map[string]string{
+	"file": "siege.urls",
+}
+
+// The smallest and largest values allowed for integer settings narrower than their field's type
+var siegeSettingRanges = map[string][2]int64{
+	"proxy-port": {1, 65535},
+}
+
+// siegeSettingsFlags exposes every SiegeConfig field as a `siege.settings.{name}` flag, named after its `siege` tag
+func siegeSettingsFlags() []cli.Flag {
+	flags := []cli.Flag{
+		altsrc.NewGenericFlag(&cli.GenericFlag{
+			Name:   "siege.settings",
+			Usage:  "override any siege.conf setting: siege.settings.{name}",
+			Value:  SiegeSettingsConfig{},
+			Hidden: true,
+		}),
+	}
+
+	configType := reflect.TypeOf(SiegeConfig{})
+	for i := 0; i < configType.NumField(); i++ {
+		fieldType := configType.Field(i)
+		name, _ := parseTag(fieldType.Tag.Get("siege"))
+		if name == "-" || managedSiegeSettings[name] != "" {
+			continue
+		}
+
+		flagName := fmt.Sprintf("siege.settings.%s", name)
+		usage := fmt.Sprintf("set `%s` in the generated siege.conf", name)
+
+		// These only come from the command line: the configuration file's `siege.settings` table arrives through the
+		// generic flag above, since altsrc would reject anything not already in the flag's own type (such as `10M` for
+		// a duration) before SetSetting could parse it. They're strings for the same reason.
+		switch fieldType.Type.Name() {
+		case "stringSlice", "Header", "urlList":
+			flags = append(flags, &cli.StringSliceFlag{Name: flagName, Usage: usage, Hidden: true})
+		default:
+			flags = append(flags, &cli.StringFlag{Name: flagName, Usage: usage, Hidden: true})
+		}
+	}
+
+	return flags
+}
+
+// ApplySettings overrides the generated configuration with anything set in `siege.settings`
+func (c *SiegeConfig) ApplySettings(ctx *cli.Context) error {
+	settings, _ := ctx.Generic("siege.settings").(SiegeSettingsConfig)
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := c.SetSetting(name, settings[name]); err != nil {
+			return err
+		}
+	}
+
+	reflected := reflect.ValueOf(c).Elem()
+	for i := 0; i < reflected.Type().NumField(); i++ {
+		fieldType := reflected.Type().Field(i)
+		name, _ := parseTag(fieldType.Tag.Get("siege"))
+
+		flagName := fmt.Sprintf("siege.settings.%s", name)
+		if !ctx.IsSet(flagName) {
+			continue
+		}
+
+		var value interface{} = ctx.String(flagName)
+		if slices.Contains([]string{"stringSlice", "Header", "urlList"}, fieldType.Type.Name()) {
+			value = ctx.StringSlice(flagName)
+		}
+
+		if err := c.SetSetting(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetSetting sets the field tagged with the given Siege setting name, validating the value's type along the way
+func (c *SiegeConfig) SetSetting(name string, value interface{}) error {
+	if flag, managed := managedSiegeSettings[name]; managed {
+		return fmt.Errorf("The Siege setting `%s` is managed by openapi2siege\n\tUse `%s` instead of `siege.settings.%s`\n", name, flag, name)
+	}
+
+	reflected := reflect.ValueOf(c).Elem()
+	for i := 0; i < reflected.Type().NumField(); i++ {
+		fieldType := reflected.Type().Field(i)
+		fieldName, _ := parseTag(fieldType.Tag.Get("siege"))
+		if fieldName != name || fieldName == "-" {
+			continue
+		}
+
+		fieldVal := reflected.Field(i)
+
+		switch fieldType.Type.Name() {
+		case "string":
+			str, isType := value.(string)
+			if !isType {
+				return settingTypeError(name, "a string", value)
+			}
+
+			fieldVal.SetString(str)
+		case "SiegeBoolTF", "SiegeBoolOO":
+			switch typed := value.(type) {
+			case bool:
+				fieldVal.SetBool(typed)
+			case string:
+				switch strings.ToLower(typed) {
+				case "true", "on":
+					fieldVal.SetBool(true)
+				case "false", "off":
+					fieldVal.SetBool(false)
+				default:
+					return settingTypeError(name, "a boolean", value)
+				}
+			default:
+				return settingTypeError(name, "a boolean", value)
+			}
+		case "int":
+			number, isNumber := settingToFloat(value)
+			if !isNumber || number != float64(int64(number)) {
+				return settingTypeError(name, "an integer", value)
+			}
+			if limits, exists := siegeSettingRanges[name]; exists && (int64(number) < limits[0] || int64(number) > limits[1]) {
+				return fmt.Errorf("The Siege setting `%s` must be between %d and %d, but got %v\n\tCheck your configuration for `siege.settings.%s`\n", name, limits[0], limits[1], value, name)
+			}
+			if fieldVal.OverflowInt(int64(number)) {
+				return fmt.Errorf("The Siege setting `%s` is out of range: %v\n\tCheck your configuration for `siege.settings.%s`\n", name, value, name)
+			}
+
+			fieldVal.SetInt(int64(number))
+		case "float64":
+			number, isNumber := settingToFloat(value)
+			if !isNumber {
+				return settingTypeError(name, "a number", value)
+			}
+
+			fieldVal.SetFloat(number)
+		case "Duration":
+			duration, err := settingToDuration(value)
+			if err != nil {
+				return settingTypeError(name, "a duration (such as `30s`, `10M`, or `1H`)", value)
+			}
+
+			fieldVal.SetInt(int64(duration))
+		case "SiegeCreds":
+			str, isType := value.(string)
+			creds := strings.SplitN(str, ":", 3)
+			if !isType || len(creds) < 2 {
+				return settingTypeError(name, "a string formatted as `{user}:{pass}` or `{user}:{pass}:{realm}`", value)
+			}
+
+			parsed := SiegeCreds{User: creds[0], Password: creds[1]}
+			if len(creds) > 2 {
+				parsed.Realm = creds[2]
+			}
+
+			fieldVal.Set(reflect.ValueOf(parsed))
+		case "stringSlice":
+			list, isList := settingToStrings(value)
+			if !isList {
+				return settingTypeError(name, "a list of strings", value)
+			}
+
+			fieldVal.Set(reflect.ValueOf(stringSlice(list)))
+		case "Header":
+			list, isList := settingToStrings(value)
+			if !isList {
+				return settingTypeError(name, "a list of `{name}: {value}` strings", value)
+			}
+
+			headers := make(http.Header)
+			for _, header := range list {
+				headerName, headerValue, found := strings.Cut(header, ":")
+				if !found {
+					return settingTypeError(name, "a list of `{name}: {value}` strings", value)
+				}
+
+				headers.Add(strings.TrimSpace(headerName), strings.TrimSpace(headerValue))
+			}
+
+			// Replace rather than append, so applying the same settings twice doesn't duplicate anything
+			for headerName, headerValues := range headers {
+				c.Headers[headerName] = headerValues
+			}
+		case "urlList":
+			list, isList := settingToStrings(value)
+			if !isList {
+				return settingTypeError(name, "a list of `{url} [{method} [{payload}]]` strings", value)
+			}
+
+			urls := make(urlList, 0, len(list))
+			for _, line := range list {
+				parts := strings.SplitN(line, " ", 3)
+
+				parsed, err := url.Parse(parts[0])
+				if err != nil {
+					return fmt.Errorf("The Siege setting `%s` contains an invalid URL: %s\n\t%v\n", name, parts[0], err)
+				}
+
+				data := urlData{URL: *parsed, Method: "GET"}
+				if len(parts) > 1 {
+					data.Method = strings.ToUpper(parts[1])
+				}
+				if len(parts) > 2 {
+					data.Payload = parts[2]
+				}
+
+				urls = append(urls, data)
+			}
+
+			fieldVal.Set(reflect.ValueOf(urls))
+		default:
+			return fmt.Errorf("The Siege setting `%s` can't be configured yet\n\tAsk us to add it!\n", name)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("Unknown Siege setting `%s`\n\tCheck your configuration for `siege.settings.%s`; valid settings are: %s\n", name, name, strings.Join(siegeSettingNames(), ", "))
+}
+
+func siegeSettingNames() []string {
+	names := make([]string, 0)
+
+	configType := reflect.TypeOf(SiegeConfig{})
+	for i := 0; i < configType.NumField(); i++ {
+		name, _ := parseTag(configType.Field(i).Tag.Get("siege"))
+		if name != "-" && managedSiegeSettings[name] == "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func settingTypeError(name, expected string, value interface{}) error {
+	return fmt.Errorf("The Siege setting `%s` must be %s, but got `%v` (%T)\n\tCheck your configuration for `siege.settings.%s`\n", name, expected, value, value, name)
+}
+
+func settingToFloat(value interface{}) (float64, bool) {
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(reflected.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(reflected.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return reflected.Float(), true
+	case reflect.String:
+		number, err := strconv.ParseFloat(strings.TrimSpace(reflected.String()), 64)
+
+		return number, err == nil
+	}
+
+	return 0, false
+}
+
+// settingToDuration accepts Go-style durations (`1m30s`), Siege-style durations (`90S`, `10M`, `1H`), or a number of
+// seconds
+func settingToDuration(value interface{}) (time.Duration, error) {
+	switch typed := value.(type) {
+	case time.Duration:
+		return typed, nil
+	case string:
+		if duration, err := time.ParseDuration(strings.ToLower(typed)); err == nil {
+			return duration, nil
+		}
+
+		seconds, err := strconv.ParseFloat(typed, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	seconds, isNumber := settingToFloat(value)
+	if !isNumber {
+		return 0, fmt.Errorf("not a duration")
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func settingToStrings(value interface{}) ([]string, bool) {
+	switch typed := value.(type) {
+	case string:
+		return []string{typed}, true
+	case []string:
+		return typed, true
+	case []interface{}:
+		list := make([]string, 0, len(typed))
+		for _, item := range typed {
+			str, isType := item.(string)
+			if !isType {
+				return nil, false
+			}
+
+			list = append(list, str)
+		}
+
+		return list, true
+	}
+
+	return nil, false
+}
+
+// SiegeVar is a variable declared in siege.conf, which Siege substitutes wherever `$(NAME)` appears
+type SiegeVar struct {
+	Name  string
+	Value string
+}
+
+// SiegeVars keeps variables in the order they're declared, since later ones can refer to earlier ones
+type SiegeVars []SiegeVar
+
+// Set declares a variable, or replaces the value of one already declared
+func (v *SiegeVars) Set(name, value string) {
+	for idx := range *v {
+		if (*v)[idx].Name == name {
+			(*v)[idx].Value = value
+			return
+		}
+	}
+
+	*v = append(*v, SiegeVar{Name: name, Value: value})
+}
 
 func (v SiegeVars) String() string {
 	output := ""
 
-	for variable, value := range v {
-		output += fmt.Sprintf("%s = %s\n", variable, value)
+	for _, variable := range v {
+		output += fmt.Sprintf("%s = %s\n", variable.Name, variable.Value)
 	}
 
 	return output
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+)
+
+// applySettingsFromFile loads `siege.settings` from a real configuration file (plus any command line flags) and
+// applies them to a fresh SiegeConfig
+func applySettingsFromFile(t *testing.T, contents string, args ...string) (*SiegeConfig, error) {
+	t.Helper()
+
+	confPath := filepath.Join(t.TempDir(), "oa2s.conf")
+	if err := os.WriteFile(confPath, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := cli.NewApp()
+	app.Flags = append([]cli.Flag{&cli.PathFlag{Name: "conf"}}, siegeSettingsFlags()...)
+	app.Before = altsrc.InitInputSourceWithContext(app.Flags, altsrc.NewTomlSourceFromFlagFunc("conf"))
+
+	conf := NewSiegeConfig()
+	app.Action = func(c *cli.Context) error {
+		return conf.ApplySettings(c)
+	}
+
+	return conf, app.Run(append([]string{"openapi2siege", "--conf", confPath}, args...))
+}
+
+func TestSiegeSettingsFromConfigFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		args     []string
+		check    func(*SiegeConfig) bool
+	}{
+		{
+			name:     "Siege-style duration",
+			contents: "[siege.settings]\ntime = \"10M\"\n",
+			check:    func(c *SiegeConfig) bool { return c.Duration == 10*time.Minute },
+		},
+		{
+			name:     "duration in seconds",
+			contents: "[siege.settings]\ntime = 600\n",
+			check:    func(c *SiegeConfig) bool { return c.Duration == 10*time.Minute },
+		},
+		{
+			name:     "dotted keys",
+			contents: "siege.settings.concurrent = 5\nsiege.settings.delay = 0.5\nsiege.settings.verbose = false\n",
+			check:    func(c *SiegeConfig) bool { return c.Concurrent == 5 && c.Delay == 0.5 && !bool(c.Verbose) },
+		},
+		{
+			name:     "headers",
+			contents: "[siege.settings]\nheader = [\"X-Test: yes\"]\n",
+			check:    func(c *SiegeConfig) bool { return c.Headers.Get("X-Test") == "yes" },
+		},
+		{
+			name:     "high proxy port",
+			contents: "[siege.settings]\nproxy-port = 65535\n",
+			check:    func(c *SiegeConfig) bool { return c.ProxyPort == 65535 },
+		},
+		{
+			name:     "login URLs",
+			contents: "[siege.settings]\nlogin-url = [\"https://example.com/login POST a=b\", \"https://example.com/other\"]\n",
+			check: func(c *SiegeConfig) bool {
+				return strings.Contains(c.String(), "login-url = https://example.com/login POST a=b\nlogin-url = https://example.com/other\n")
+			},
+		},
+		{
+			name:     "command line overrides the file",
+			contents: "[siege.settings]\ntime = \"10M\"\n",
+			args:     []string{"--siege.settings.time", "1H", "--siege.settings.color", "off"},
+			check:    func(c *SiegeConfig) bool { return c.Duration == time.Hour && !bool(c.Color) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := applySettingsFromFile(t, test.contents, test.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !test.check(conf) {
+				t.Errorf("settings not applied: %+v", conf)
+			}
+		})
+	}
+}
+
+func TestSiegeSettingsFromConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		message  string
+	}{
+		{
+			name:     "not an integer",
+			contents: "[siege.settings]\nconcurrent = \"lots\"\n",
+			message:  "The Siege setting `concurrent` must be an integer",
+		},
+		{
+			name:     "not a duration",
+			contents: "[siege.settings]\ntime = \"soon\"\n",
+			message:  "The Siege setting `time` must be a duration",
+		},
+		{
+			name:     "port out of range",
+			contents: "[siege.settings]\nproxy-port = 70000\n",
+			message:  "The Siege setting `proxy-port` must be between 1 and 65535",
+		},
+		{
+			name:     "managed setting",
+			contents: "[siege.settings]\nfile = \"other.txt\"\n",
+			message:  "managed by openapi2siege",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := applySettingsFromFile(t, test.contents)
+			if err == nil || !strings.Contains(err.Error(), test.message) {
+				t.Errorf("expected an error containing %q, got %v", test.message, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// Ways to write hosts, server variables, and secrets into the generated files
+const (
+	variablesOff  = "off"
+	variablesConf = "conf"
+	variablesEnv  = "env"
+)
+
+var siegeVariableInvalidChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// serverTemplate is a server URL with `{name}` placeholders, and the values chosen for them
+type serverTemplate struct {
+	URL       string
+	Variables map[string]string
+}
+
+// siegeVariableName builds a variable name from its parts, such as `OA2S_SERVER_REGION` from `server` and `region`
+func siegeVariableName(parts ...string) string {
+	name := "OA2S"
+	for _, part := range parts {
+		name += "_" + strings.ToUpper(strings.Trim(siegeVariableInvalidChars.ReplaceAllString(part, "_"), "_"))
+	}
+
+	return name
+}
+
+func siegeVariableRef(name string) string {
+	return fmt.Sprintf("$(%s)", name)
+}
+
+// UseVariables switches the config over to Siege variables, according to `siege.variables`. The base URL becomes
+// `$(OA2S_BASE_URL)`, which is built from a variable per server variable, so URLs can be pointed at another host
+// without regenerating them; credentials are added as variables as they're applied.
+func (c *SiegeConfig) UseVariables(mode string, baseUrl *url.URL, server serverTemplate) error {
+	switch mode {
+	case variablesOff:
+		return nil
+	case variablesConf, variablesEnv:
+	default:
+		return fmt.Errorf("Unknown variable mode `%s`\n\tCheck your configuration for `siege.variables`; it should be `%s`, `%s`, or `%s`\n", mode, variablesOff, variablesConf, variablesEnv)
+	}
+
+	c.VariableMode = mode
+	c.BaseURL = strings.TrimSuffix(baseUrl.String(), "/")
+
+	template := strings.TrimSuffix(server.URL, "/")
+
+	names := maps.Keys(server.Variables)
+	sort.Strings(names)
+
+	for _, name := range names {
+		variable := siegeVariableName("server", name)
+
+		// Only the base URL refers to these, so they're no use when it comes from the environment
+		if mode == variablesConf {
+			c.Variables.Set(variable, server.Variables[name])
+		}
+
+		template = strings.ReplaceAll(template, fmt.Sprintf("{%s}", name), siegeVariableRef(variable))
+	}
+
+	c.Variables.Set(siegeVariableName("base", "url"), template)
+
+	return nil
+}
+
+// Secret returns the value to write for a credential: the value itself, or a reference to a new variable holding it,
+// named for the security scheme and the kind of credential
+func (c *SiegeConfig) Secret(scheme, kind, value string) string {
+	if c.VariableMode == "" {
+		return value
+	}
+
+	name := siegeVariableName(scheme, kind)
+	c.Variables.Set(name, value)
+
+	return siegeVariableRef(name)
+}
+
+// UsesVariables reports whether the config writes credentials as variables
+func (c *SiegeConfig) UsesVariables() bool {
+	return c.VariableMode != ""
+}
+
+// ReplaceBaseURL swaps the base URL at the start of each line of a URLs file for its variable. URLs using other
+// servers (from operation-level `servers`, or `schemes` in OpenAPI v2) are left alone.
+func (c *SiegeConfig) ReplaceBaseURL(urls string) string {
+	if c.BaseURL == "" {
+		return urls
+	}
+
+	lines := strings.Split(urls, "\n")
+	for idx, line := range lines {
+		rest := strings.TrimPrefix(line, c.BaseURL)
+
+		// Make sure the base URL ends here, rather than partway through a path segment or host name
+		if len(rest) < len(line) && (rest == "" || strings.ContainsAny(rest[:1], "/?# ")) {
+			lines[idx] = siegeVariableRef(siegeVariableName("base", "url")) + rest
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// EnvironmentVariables lists the variables Siege needs from the environment, when their values are left out
+func (c *SiegeConfig) EnvironmentVariables() []string {
+	if c.VariableMode != variablesEnv {
+		return nil
+	}
+
+	return mapSlice(c.Variables, func(variable SiegeVar) string {
+		return variable.Name
+	})
+}
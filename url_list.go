@@ -6,8 +6,6 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
-
-	cookiejar "github.com/juju/persistent-cookiejar"
 )
 
 type requestData struct {
@@ -21,6 +19,7 @@ type urlData struct {
 	MediaType string
 	Payload   string
 	Cookies   []*http.Cookie
+	Security  map[string][]string
 }
 
 type urlList []urlData
@@ -55,21 +54,6 @@ func (l urlList) MediaTypes() []string {
 	}))
 }
 
-func (l urlList) CookieJar(file string) (*cookiejar.Jar, error) {
-	jar, err := cookiejar.New(&cookiejar.Options{Filename: file})
-	if err != nil {
-		return nil, err
-	}
-
-	for _, data := range l {
-		if len(data.Cookies) > 0 {
-			jar.SetCookies(&data.URL, data.Cookies)
-		}
-	}
-
-	return jar, nil
-}
-
 func mapSlice[T, U any](data []T, f func(T) U) []U {
 	res := make([]U, 0, len(data))
 
@@ -96,3 +80,22 @@ func uniqueSlice[T comparable](data []T) []T {
 
 	return res
 }
+
+// mergeParameters combines path-level and operation-level parameters, with operation-level parameters overriding any
+// path-level parameters that share the same key (OpenAPI uses the combination of name and location for this)
+func mergeParameters[T any](pathParams, methodParams []T, key func(T) string) []T {
+	overrides := make(map[string]bool)
+	for _, param := range methodParams {
+		overrides[key(param)] = true
+	}
+
+	res := make([]T, 0, len(pathParams)+len(methodParams))
+
+	for _, param := range pathParams {
+		if !overrides[key(param)] {
+			res = append(res, param)
+		}
+	}
+
+	return append(res, methodParams...)
+}
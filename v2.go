@@ -2,12 +2,520 @@ package main
 
 import (
 	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
 	v2 "github.com/pb33f/libopenapi/datamodel/high/v2"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
 )
 
-func handleV2Spec(c *cli.Context, spec *libopenapi.DocumentModel[v2.Swagger]) (urlList, *SiegeConfig, error) {
-	return nil, nil, fmt.Errorf("OpenAPI 2 (Swagger) documents are not yet implemented.\nTHIS WILL HAPPEN IN A FUTURE RELEASE.\n")
+func handleV2Spec(c *cli.Context, spec *libopenapi.DocumentModel[v2.Swagger]) ([]authGroup, error) {
+	urls := urlList{}
+	paths := map[string]*v2.PathItem{}
+
+	if spec.Model.Paths != nil {
+		paths = spec.Model.Paths.PathItems
+	}
+
+	paramDefaults, _ := c.Generic("params").(ParamsConfig)
+
+	pathsConfig, isType := c.Generic("paths").(PathsConfig)
+	if !isType {
+		return nil, fmt.Errorf("Paths not configured.\n\tNeed `paths.{path}.{method}.params.{name}` and/or `paths.{path}.{method}.payloads.{mediaType}`\n")
+	}
+
+	gen, err := newPayloadGenerator(c, spec.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newOperationReport(c)
+
+	// Iterate paths in the same order every invocation
+	pathList := maps.Keys(paths)
+	sort.Strings(pathList)
+
+	for _, rawPath := range pathList {
+		pathData := paths[rawPath]
+		pathConfig, exists, err := getPathConfig(pathsConfig, paramDefaults, rawPath, report.auto)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("Path `%s` not configured.\n\tNeed `paths.%s.{method}.params.{name}` and/or `paths.%s.{method}.payloads.{mediaType}`, a matching wildcard path, or `params.{name}` defaults\n", rawPath, rawPath, rawPath)
+		}
+
+		lowPathData := pathData.GoLow()
+		operations := map[string]*v2.Operation{
+			"get":     pathData.Get,
+			"post":    pathData.Post,
+			"delete":  pathData.Delete,
+			"patch":   pathData.Patch,
+			"put":     pathData.Put,
+			"head":    pathData.Head,
+			"options": pathData.Options,
+		}
+		operationNodes := map[string]*yaml.Node{
+			"get":     lowPathData.Get.ValueNode,
+			"post":    lowPathData.Post.ValueNode,
+			"delete":  lowPathData.Delete.ValueNode,
+			"patch":   lowPathData.Patch.ValueNode,
+			"put":     lowPathData.Put.ValueNode,
+			"head":    lowPathData.Head.ValueNode,
+			"options": lowPathData.Options.ValueNode,
+		}
+
+		// Same order as the v3 handler, so output is comparable between spec versions
+		for _, method := range []string{"get", "post", "delete", "patch", "put", "head", "options"} {
+			methodData := operations[method]
+			if methodData == nil || methodData.Deprecated {
+				continue
+			}
+
+			security := getOperationSecurity(spec.Model.Security, methodData.Security, operationNodes[method])
+
+			urls, err = report.keep(method, rawPath, urls)(getV2Request(c, gen, method, rawPath, &spec.Model, urls, pathData, methodData, security, pathConfig))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	report.printSummary()
+
+	variables := c.String("siege.variables")
+
+	var baseUrl *url.URL
+	var server serverTemplate
+
+	if variables != variablesOff {
+		baseUrl, server, err = getV2Server(c, &spec.Model, spec.Model.Schemes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	newConfig := func() (*SiegeConfig, error) {
+		conf := NewSiegeConfig()
+
+		if len(spec.Model.Produces) > 0 {
+			conf.Headers.Set("Accept", strings.Join(spec.Model.Produces, ", "))
+		}
+
+		return conf, conf.UseVariables(variables, baseUrl, server)
+	}
+
+	return groupBySecurity(urls, newConfig, func(name string, scopes []string, urls urlList, conf *SiegeConfig) error {
+		return applyV2SecurityScheme(c, spec, name, scopes, urls, conf)
+	})
+}
+
+func applyV2SecurityScheme(c *cli.Context, spec *libopenapi.DocumentModel[v2.Swagger], name string, scopes []string, urls urlList, conf *SiegeConfig) error {
+	auth, _ := c.Generic("auth").(AuthConfig)
+
+	var scheme *v2.SecurityScheme
+
+	if spec.Model.SecurityDefinitions != nil {
+		scheme = spec.Model.SecurityDefinitions.Definitions[name]
+	}
+
+	if scheme == nil {
+		return fmt.Errorf("Auth scheme %s not defined in the spec's securityDefinitions\n", name)
+	}
+
+	switch scheme.Type {
+	case "basic":
+		return applyLoginAuth(name, auth, conf)
+	case "apiKey":
+		return applyApiKeyAuth(name, scheme.In, scheme.Name, auth, urls, conf)
+	case "oauth2":
+		flows := make([]oauth2Flow, 0)
+		switch scheme.Flow {
+		case "application":
+			flows = append(flows, oauth2Flow{Grant: "client_credentials", TokenUrl: scheme.TokenUrl})
+		case "password":
+			flows = append(flows, oauth2Flow{Grant: "password", TokenUrl: scheme.TokenUrl})
+		}
+
+		return applyOAuth2Auth(name, flows, scopes, auth, filepath.Dir(c.Path("siege.config")), conf)
+	default:
+		return fmt.Errorf("Unrecognized security scheme `%s` used in %s\n\tOpenAPI v2 doesn't support this authentication type, so we don't know how to proceed\n", scheme.Type, name)
+	}
+}
+
+func getV2Request(c *cli.Context, gen *payloadGenerator, method, rawPath string, spec *v2.Swagger, urls urlList, pathData *v2.PathItem, methodData *v2.Operation, requirements []*base.SecurityRequirement, pathConfig PathConfig) (urlList, error) {
+	methodConfig, exists := pathConfig[method]
+	if !exists {
+		return nil, fmt.Errorf("`%s %s` not configured.\n\tNeed `paths.%s.%s.params.{name}` and/or `paths.%s.%s.payloads.{mediaType}` (or `*` in place of the method)\n", strings.ToUpper(method), rawPath, rawPath, strings.ToLower(method), rawPath, strings.ToLower(method))
+	}
+
+	schemes := methodData.Schemes
+	if len(schemes) < 1 {
+		schemes = spec.Schemes
+	}
+
+	baseUrl, err := getV2BaseUrl(c, spec, schemes)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, _ := c.Generic("auth").(AuthConfig)
+
+	security, err := chooseSecurityRequirement(method, rawPath, requirements, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	params := mergeParameters(pathData.Parameters, methodData.Parameters, v2ParameterKey)
+
+	consumes := methodData.Consumes
+	if len(consumes) < 1 {
+		consumes = spec.Consumes
+	}
+	if len(consumes) < 1 {
+		consumes = []string{"application/json"}
+	}
+
+	err = gen.eachVariant(func(gen *payloadGenerator) error {
+		path, query, form, err := getV2PathParams(c, gen, method, rawPath, params, methodConfig)
+		if err != nil {
+			return err
+		}
+
+		pathUrl := baseUrl.JoinPath(path)
+		pathUrl.RawQuery = query.Encode()
+
+		if method == "get" || method == "head" {
+			urls = append(urls, urlData{
+				URL:       *pathUrl,
+				Method:    strings.ToUpper(method),
+				MediaType: "",
+				Payload:   "",
+				Security:  security,
+			})
+
+			return nil
+		}
+
+		requests, err := getV2PathPayloads(c, gen, method, rawPath, params, form, consumes, methodConfig)
+		if err != nil {
+			return err
+		}
+
+		for _, request := range requests {
+			urls = append(urls, urlData{
+				URL:       *pathUrl,
+				Method:    strings.ToUpper(method),
+				MediaType: request.MediaType,
+				Payload:   request.Payload,
+				Security:  security,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+func getV2BaseUrl(c *cli.Context, spec *v2.Swagger, schemes []string) (*url.URL, error) {
+	baseUrl, _, err := getV2Server(c, spec, schemes)
+
+	return baseUrl, err
+}
+
+// getV2Server resolves the base URL, also returning it as a template of the `scheme`, `host`, and `basePath` server
+// variables for use with `siege.variables`
+func getV2Server(c *cli.Context, spec *v2.Swagger, schemes []string) (*url.URL, serverTemplate, error) {
+	config, _ := c.Generic("server.variables").(ServerVarsConfig)
+
+	host := config["host"]
+	if host == "" {
+		host = spec.Host
+	}
+	if host == "" {
+		return nil, serverTemplate{}, fmt.Errorf("No host defined in the spec.\n\tCheck your configuration for `server.variables.host`\n")
+	}
+
+	basePath := config["basePath"]
+	if basePath == "" {
+		basePath = spec.BasePath
+	}
+
+	scheme := config["scheme"]
+	if scheme == "" {
+		switch {
+		case slices.Contains(schemes, "https"):
+			scheme = "https"
+		case slices.Contains(schemes, "http"):
+			scheme = "http"
+		case len(schemes) > 0:
+			return nil, serverTemplate{}, fmt.Errorf("Siege only supports the `http` and `https` schemes, but the spec only offers %v.\n\tCheck your configuration for `server.variables.scheme`\n", schemes)
+		default:
+			scheme = "http"
+		}
+	}
+
+	baseUrl, err := url.Parse(fmt.Sprintf("%s://%s%s", scheme, host, basePath))
+	if err != nil {
+		return nil, serverTemplate{}, err
+	}
+
+	server := serverTemplate{
+		URL:       "{scheme}://{host}",
+		Variables: map[string]string{"scheme": scheme, "host": host},
+	}
+
+	if basePath != "" {
+		server.URL += "{basePath}"
+		server.Variables["basePath"] = basePath
+	}
+
+	return baseUrl, server, nil
+}
+
+func getV2PathParams(c *cli.Context, gen *payloadGenerator, method, rawPath string, params []*v2.Parameter, config PathMethodConfig) (string, url.Values, url.Values, error) {
+	path := rawPath
+	query := make(url.Values)
+	form := make(url.Values)
+
+	for _, param := range params {
+		// File uploads are added when building multipart payloads
+		if param.In == "body" || param.Type == "file" {
+			continue
+		}
+
+		var paramValue interface{}
+
+		required := param.Required != nil && *param.Required
+		configValue, exists := config.Params[param.Name]
+		if !exists && required {
+			// The parameter's default is more specific than its enum, which is only looked up without one, so an unused
+			// enum never adds variants of its own
+			var enumValue interface{}
+			var hasEnum bool
+			if param.Default == nil {
+				enumValue, hasEnum = getV2ParamEnumValue(gen, param)
+			}
+
+			switch {
+			case param.Default != nil:
+				paramValue = param.Default
+			case hasEnum:
+				paramValue = enumValue
+			case param.AllowEmptyValue != nil && *param.AllowEmptyValue:
+				paramValue = ""
+			case gen.auto:
+				fakeValue, err := fakeV2ParamValue(gen, param)
+				if err != nil {
+					return "", nil, nil, fmt.Errorf("Couldn't generate a value for %s in %s %s\n\t%v\n\tNeed paths.%s.%s.params.%s\n", param.Name, strings.ToUpper(method), rawPath, err, rawPath, strings.ToLower(method), param.Name)
+				}
+
+				paramValue = fakeValue
+			default:
+				return "", nil, nil, fmt.Errorf("Unconfigured value for %s in %s %s, with no default to draw from\n\tNeed paths.%s.%s.params.%s\n", param.Name, strings.ToUpper(method), rawPath, rawPath, strings.ToLower(method), param.Name)
+			}
+		}
+
+		if exists {
+			var err error
+
+			paramValue, err = gen.paramConfigValue(configValue)
+			if err != nil {
+				return "", nil, nil, fmt.Errorf("Couldn't read the value for %s in %s %s\n\t%v\n\tCheck paths.%s.%s.params.%s\n", param.Name, strings.ToUpper(method), rawPath, err, rawPath, strings.ToLower(method), param.Name)
+			}
+		}
+
+		if exists || required {
+			style, explode := getV2ParamStyle(param.In, param.CollectionFormat)
+
+			switch param.In {
+			case "path":
+				path = strings.ReplaceAll(path, fmt.Sprintf("{%s}", param.Name), serializePathParam(param.Name, paramValue, style, explode))
+			case "query":
+				for key, values := range serializeQueryParam(param.Name, paramValue, style, explode) {
+					query[key] = append(query[key], values...)
+				}
+			case "header":
+				fmt.Printf("Per-request headers are unsupported by Siege; your tests may not work as expected\n\tSkipping %s for %s\n", param.Name, rawPath)
+			case "formData":
+				for key, values := range serializeQueryParam(param.Name, paramValue, style, explode) {
+					form[key] = append(form[key], values...)
+				}
+			}
+		}
+	}
+
+	return path, query, form, nil
+}
+
+// getV2ParamEnumValue picks an enum member for a parameter. Array parameters list their members under `items`, so
+// the member becomes the array's only item.
+func getV2ParamEnumValue(gen *payloadGenerator, param *v2.Parameter) (interface{}, bool) {
+	if param.Type != "array" {
+		return gen.pickFromEnum(decodeEnum(param.GoLow().Enum, param.Enum))
+	}
+
+	if param.Items == nil {
+		return nil, false
+	}
+
+	member, hasEnum := gen.pickFromEnum(decodeEnum(param.Items.GoLow().Enum, param.Items.Enum))
+	if !hasEnum {
+		return nil, false
+	}
+
+	return []interface{}{member}, true
+}
+
+func getV2PathPayloads(c *cli.Context, gen *payloadGenerator, method, rawPath string, params []*v2.Parameter, form url.Values, consumes []string, config PathMethodConfig) ([]requestData, error) {
+	payloads := make([]requestData, 0)
+
+	bodyIdx := slices.IndexFunc(params, func(param *v2.Parameter) bool {
+		return param.In == "body"
+	})
+
+	for _, mediatype := range consumes {
+		payload := ""
+
+		configured, exists := config.Payloads[mediatype]
+		if exists {
+			var err error
+
+			payload, err = getConfiguredPayload(c, mediatype, configured)
+			if err != nil {
+				return nil, fmt.Errorf("Couldn't use the configured payload for %s in %s %s\n\t%v\n\tCheck paths.%s.%s.payloads.%s\n", mediatype, strings.ToUpper(method), rawPath, err, rawPath, method, mediatype)
+			}
+		} else {
+			switch {
+			case bodyIdx >= 0:
+				body := params[bodyIdx]
+				// `--auto` fills in optional bodies too, since that's what most operations are for
+				optional := body.Required == nil || !*body.Required
+				if body.Schema == nil || (optional && !gen.auto) {
+					continue
+				}
+
+				fakePayload, err := gen.createFakePayload(body.Schema)
+				if err != nil {
+					return nil, err
+				}
+
+				if fakePayload == nil {
+					continue
+				}
+
+				fakePayload, err = gen.applyDataFields(fakePayload, body.Schema, config.Fields)
+				if err != nil {
+					return nil, fmt.Errorf("Couldn't fill in the payload fields for %s %s\n\t%v\n\tCheck paths.%s.%s.fields\n", strings.ToUpper(method), rawPath, err, rawPath, method)
+				}
+
+				if isXMLMediaType(mediatype) {
+					payload, err = encodeXMLPayload(fakePayload, body.Schema)
+				} else {
+					payload, err = getPayloadFromType(mediatype, fakePayload, nil)
+				}
+				if err != nil {
+					fmt.Printf("%v\n\tSkipping %s for %s %s\n", err, mediatype, strings.ToUpper(method), rawPath)
+					continue
+				}
+
+				payload, err = getURLFilePayload(c, mediatype, payload)
+				if err != nil {
+					return nil, err
+				}
+			case isMultipartMediaType(mediatype) && (len(form) > 0 || hasV2FileParams(params, config)):
+				var err error
+
+				payload, err = getV2MultipartPayload(c, params, form, config)
+				if err != nil {
+					return nil, err
+				}
+			case len(form) > 0:
+				if !isFormMediaType(mediatype) {
+					fmt.Printf("Form data can't be sent as %s yet; your tests will be incomplete\n\tSkipping %s for %s %s\n", mediatype, mediatype, strings.ToUpper(method), rawPath)
+					continue
+				}
+
+				payload = form.Encode()
+			default:
+				continue
+			}
+		}
+
+		if payload != "" {
+			payloads = append(payloads, requestData{MediaType: getPayloadMediaType(mediatype), Payload: payload})
+		}
+	}
+
+	if bodyIdx >= 0 && params[bodyIdx].Required != nil && *params[bodyIdx].Required && len(payloads) < 1 {
+		return nil, fmt.Errorf("Unconfigured payload in %s %s, and couldn't generate one\n\tNeed paths.%s.%s.payloads.{mediaType}\n", strings.ToUpper(method), rawPath, rawPath, method)
+	}
+
+	if len(payloads) < 1 {
+		payloads = append(payloads, requestData{MediaType: "", Payload: "\"\""})
+	}
+
+	return payloads, nil
+}
+
+// getV2MultipartPayload builds a multipart/form-data body from the form parameters, with a part for each required or
+// configured file parameter
+func getV2MultipartPayload(c *cli.Context, params []*v2.Parameter, form url.Values, config PathMethodConfig) (string, error) {
+	names := maps.Keys(form)
+	sort.Strings(names)
+
+	parts := make([]multipartPart, 0, len(names))
+
+	for _, name := range names {
+		for _, value := range form[name] {
+			parts = append(parts, multipartPart{Name: name, Content: []byte(value)})
+		}
+	}
+
+	for _, param := range params {
+		if !isV2FileParam(param, config) {
+			continue
+		}
+
+		part, err := newMultipartFilePart(c, param.Name, "", config.Files[param.Name])
+		if err != nil {
+			return "", err
+		}
+
+		parts = append(parts, part)
+	}
+
+	return writeMultipartPayload(c, parts)
+}
+
+func hasV2FileParams(params []*v2.Parameter, config PathMethodConfig) bool {
+	return slices.IndexFunc(params, func(param *v2.Parameter) bool {
+		return isV2FileParam(param, config)
+	}) >= 0
+}
+
+// isV2FileParam reports whether a file parameter should be uploaded: either it's required, or it has a sample file
+func isV2FileParam(param *v2.Parameter, config PathMethodConfig) bool {
+	if param.In != "formData" || param.Type != "file" {
+		return false
+	}
+
+	_, configured := config.Files[param.Name]
+
+	return configured || (param.Required != nil && *param.Required)
+}
+
+func v2ParameterKey(param *v2.Parameter) string {
+	return fmt.Sprintf("%s:%s", param.In, param.Name)
 }
@@ -4,73 +4,87 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
-func handleV3Spec(c *cli.Context, spec *libopenapi.DocumentModel[v3.Document]) (urlList, *SiegeConfig, error) {
+func handleV3Spec(c *cli.Context, spec *libopenapi.DocumentModel[v3.Document]) ([]authGroup, error) {
 	urls := urlList{}
-	conf := NewSiegeConfig()
 	paths := spec.Model.Paths.PathItems
 
-	baseUrl, err := getV3BaseUrl(c, spec.Model.Servers)
+	baseUrl, server, err := getV3Server(c, spec.Model.Servers)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
+	paramDefaults, _ := c.Generic("params").(ParamsConfig)
+
 	pathsConfig, isType := c.Generic("paths").(PathsConfig)
 	if !isType {
-		return nil, nil, fmt.Errorf("Paths not configured.\n\tNeed `paths.{path}.{method}.params.{name}` and/or `paths.{path}.{method}.payloads.{mediaType}`\n")
+		return nil, fmt.Errorf("Paths not configured.\n\tNeed `paths.{path}.{method}.params.{name}` and/or `paths.{path}.{method}.payloads.{mediaType}`\n")
+	}
+
+	gen, err := newPayloadGenerator(c, spec.Index)
+	if err != nil {
+		return nil, err
 	}
 
+	report := newOperationReport(c)
+
 	// Iterate paths in the same order every invocation
 	pathList := maps.Keys(paths)
 	sort.Strings(pathList)
 
 	for _, rawPath := range pathList {
 		pathData := paths[rawPath]
-		pathConfig, exists := pathsConfig[rawPath]
+		pathConfig, exists, err := getPathConfig(pathsConfig, paramDefaults, rawPath, report.auto)
+		if err != nil {
+			return nil, err
+		}
 		if !exists {
-			return nil, nil, fmt.Errorf("Path `%s` not configured.\n\tNeed `paths.%s.{method}.params.{name}` and/or `paths.%s.{method}.payloads.{mediaType}`\n", rawPath, rawPath, rawPath)
+			return nil, fmt.Errorf("Path `%s` not configured.\n\tNeed `paths.%s.{method}.params.{name}` and/or `paths.%s.{method}.payloads.{mediaType}`, a matching wildcard path, or `params.{name}` defaults\n", rawPath, rawPath, rawPath)
 		}
 
 		if pathData.Get != nil && !*pathData.Get.Deprecated {
-			urls, err = getV3RequestNoPayload(c, "get", rawPath, baseUrl, urls, pathData.Get, pathConfig)
+			urls, err = report.keep("get", rawPath, urls)(getV3RequestNoPayload(c, gen, "get", rawPath, baseUrl, urls, pathData.Get, pathData.Parameters, getOperationSecurity(spec.Model.Security, pathData.Get.Security, pathData.GoLow().Get.ValueNode), pathConfig))
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 		}
 
 		if pathData.Post != nil && !*pathData.Post.Deprecated {
-			urls, err = getV3RequestWithPayload(c, "post", rawPath, baseUrl, urls, pathData.Post, pathConfig)
+			urls, err = report.keep("post", rawPath, urls)(getV3RequestWithPayload(c, gen, "post", rawPath, baseUrl, urls, pathData.Post, pathData.Parameters, getOperationSecurity(spec.Model.Security, pathData.Post.Security, pathData.GoLow().Post.ValueNode), pathConfig))
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 		}
 
 		if pathData.Delete != nil && !*pathData.Delete.Deprecated {
-			urls, err = getV3RequestWithPayload(c, "delete", rawPath, baseUrl, urls, pathData.Delete, pathConfig)
+			urls, err = report.keep("delete", rawPath, urls)(getV3RequestWithPayload(c, gen, "delete", rawPath, baseUrl, urls, pathData.Delete, pathData.Parameters, getOperationSecurity(spec.Model.Security, pathData.Delete.Security, pathData.GoLow().Delete.ValueNode), pathConfig))
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 		}
 
 		if pathData.Patch != nil && !*pathData.Patch.Deprecated {
-			urls, err = getV3RequestWithPayload(c, "patch", rawPath, baseUrl, urls, pathData.Patch, pathConfig)
+			urls, err = report.keep("patch", rawPath, urls)(getV3RequestWithPayload(c, gen, "patch", rawPath, baseUrl, urls, pathData.Patch, pathData.Parameters, getOperationSecurity(spec.Model.Security, pathData.Patch.Security, pathData.GoLow().Patch.ValueNode), pathConfig))
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 		}
 
 		if pathData.Put != nil && !*pathData.Put.Deprecated {
-			urls, err = getV3RequestWithPayload(c, "put", rawPath, baseUrl, urls, pathData.Put, pathConfig)
+			urls, err = report.keep("put", rawPath, urls)(getV3RequestWithPayload(c, gen, "put", rawPath, baseUrl, urls, pathData.Put, pathData.Parameters, getOperationSecurity(spec.Model.Security, pathData.Put.Security, pathData.GoLow().Put.ValueNode), pathConfig))
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 		}
 
@@ -79,119 +93,113 @@ func handleV3Spec(c *cli.Context, spec *libopenapi.DocumentModel[v3.Document]) (
 		}
 
 		if pathData.Head != nil && !*pathData.Head.Deprecated {
-			urls, err = getV3RequestNoPayload(c, "head", rawPath, baseUrl, urls, pathData.Head, pathConfig)
+			urls, err = report.keep("head", rawPath, urls)(getV3RequestNoPayload(c, gen, "head", rawPath, baseUrl, urls, pathData.Head, pathData.Parameters, getOperationSecurity(spec.Model.Security, pathData.Head.Security, pathData.GoLow().Head.ValueNode), pathConfig))
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 		}
 
 		if pathData.Options != nil && !*pathData.Options.Deprecated {
-			urls, err = getV3RequestWithPayload(c, "options", rawPath, baseUrl, urls, pathData.Options, pathConfig)
+			urls, err = report.keep("options", rawPath, urls)(getV3RequestWithPayload(c, gen, "options", rawPath, baseUrl, urls, pathData.Options, pathData.Parameters, getOperationSecurity(spec.Model.Security, pathData.Options.Security, pathData.GoLow().Options.ValueNode), pathConfig))
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 		}
 	}
 
-	for _, sec := range spec.Model.Security {
-		auth, isType := c.Generic("auth").(AuthConfig)
-		if !isType {
-			return nil, nil, fmt.Errorf("Auth not configured!\n\tNeed `auth.{scheme_name}.*\n")
-		}
+	report.printSummary()
+
+	newConfig := func() (*SiegeConfig, error) {
+		conf := NewSiegeConfig()
+
+		return conf, conf.UseVariables(c.String("siege.variables"), baseUrl, server)
+	}
+
+	return groupBySecurity(urls, newConfig, func(name string, scopes []string, urls urlList, conf *SiegeConfig) error {
+		return applyV3SecurityScheme(c, spec, name, scopes, urls, conf)
+	})
+}
+
+func applyV3SecurityScheme(c *cli.Context, spec *libopenapi.DocumentModel[v3.Document], name string, scopes []string, urls urlList, conf *SiegeConfig) error {
+	var err error
+
+	auth, _ := c.Generic("auth").(AuthConfig)
+
+	scheme, exists := spec.Model.Components.SecuritySchemes[name]
+	if !exists {
+		return fmt.Errorf("Auth scheme %s not configured\n\tNeed `auth.%s.*\n", name, name)
+	}
 
-		for name := range sec.Requirements {
-			scheme, exists := spec.Model.Components.SecuritySchemes[name]
+	switch scheme.Type {
+	case "apiKey":
+		if err = applyApiKeyAuth(name, scheme.In, scheme.Name, auth, urls, conf); err != nil {
+			return err
+		}
+	case "http":
+		switch scheme.Scheme {
+		case "basic", "digest":
+			if err = applyLoginAuth(name, auth, conf); err != nil {
+				return err
+			}
+		case "bearer":
+			creds, exists := auth[name]["creds"]
 			if !exists {
-				return nil, nil, fmt.Errorf("Auth scheme %s not configured\n\tNeed `auth.%s.*\n", name, name)
+				return fmt.Errorf("Credentials not configured for %s scheme\n\tNeed `auth.%s.creds`\n", name, name)
 			}
 
-			switch scheme.Type {
-			case "apiKey":
-				key, exists := auth[name]["apikey"]
-				if !exists {
-					return nil, nil, fmt.Errorf("API Key not configured for %s scheme\n\tNeed `auth.%s.apikey`\n", name, name)
-				}
+			if creds != "command" {
+				conf.Headers.Add("Authorization", fmt.Sprintf("Bearer %s", conf.Secret(name, "token", creds)))
+				fmt.Println("The HTTP auth scheme `bearer` is supported on a best-effort basis.\n\tSiege does NOT actively support bearer tokens; expiration handling is up to you.")
+			} else {
+				conf.Headers.Add("Authorization", "Bearer ${OA2S_TOKEN}")
+				fmt.Println("The HTTP auth scheme `bearer` is supported on a best-effort basis.\n\tSiege does NOT actively support bearer tokens; you need to manually set your current token in the OA2S_TOKEN environment variable.")
+			}
+		default:
+			return fmt.Errorf("The HTTP auth scheme %s (used in %s) is not currently supported.\n\tContact us to get it added!\n", scheme.Scheme, name)
+		}
+	case "mutualTLS":
+		cert, certExists := auth[name]["cert"]
+		key, keyExists := auth[name]["key"]
+		if !certExists || !keyExists {
+			return fmt.Errorf("Certificate and/or key not configured for %s scheme\n\tNeed `auth.%s.cert` and `auth.%s.key`\n", name, name, name)
+		}
 
-				switch scheme.In {
-				case "query":
-					for _, url := range urls {
-						query := url.URL.Query()
-						query.Add(scheme.Name, key)
-						url.URL.RawQuery = query.Encode()
-					}
-				case "header":
-					conf.Headers.Add(scheme.Name, key)
-				case "cookie":
-					for _, url := range urls {
-						cookie := http.Cookie{
-							Name:  scheme.Name,
-							Value: key,
-						}
-						url.Cookies = append(url.Cookies, &cookie)
-					}
-				}
-			case "http":
-				creds, exists := auth[name]["creds"]
-				if !exists {
-					return nil, nil, fmt.Errorf("Credentials not configured for %s scheme\n\tNeed `auth.%s.creds`\n", name, name)
-				}
+		conf.SslUserCert = cert
+		conf.SslUserKey = key
+	case "oauth2":
+		flows := make([]oauth2Flow, 0)
+		if scheme.Flows != nil && scheme.Flows.ClientCredentials != nil {
+			flows = append(flows, oauth2Flow{Grant: "client_credentials", TokenUrl: scheme.Flows.ClientCredentials.TokenUrl})
+		}
+		if scheme.Flows != nil && scheme.Flows.Password != nil {
+			flows = append(flows, oauth2Flow{Grant: "password", TokenUrl: scheme.Flows.Password.TokenUrl})
+		}
 
-				switch scheme.Scheme {
-				case "basic":
-					basicCreds := strings.SplitN(creds, ":", 2)
-					if len(basicCreds) < 2 {
-						return nil, nil, fmt.Errorf("Credentials incorrect for %s scheme\n\tNeed `auth.%s.creds` to be `{user}:{pass}` or `{user}:{pass}:{realm}\n", name, name)
-					}
-					conf.LoginInfo.User = basicCreds[0]
-					conf.LoginInfo.Password = basicCreds[1]
-					conf.LoginInfo.Realm = basicCreds[2]
-				case "digest":
-					digestCreds := strings.SplitN(creds, ":", 2)
-					if len(digestCreds) < 2 {
-						return nil, nil, fmt.Errorf("Credentials incorrect for %s scheme\n\tNeed `auth.%s.creds` to be `{user}:{pass}` or `{user}:{pass}:{realm}\n", name, name)
-					}
-					conf.LoginInfo.User = digestCreds[0]
-					conf.LoginInfo.Password = digestCreds[1]
-					conf.LoginInfo.Realm = digestCreds[2]
-				case "bearer":
-					if creds != "command" {
-						conf.Headers.Add("Authorization", fmt.Sprintf("Bearer %s", creds))
-						fmt.Println("The HTTP auth scheme `bearer` is supported on a best-effort basis.\n\tSiege does NOT actively support bearer tokens; expiration handling is up to you.")
-					} else {
-						conf.Headers.Add("Authorization", "Bearer ${OA2S_TOKEN}")
-						fmt.Println("The HTTP auth scheme `bearer` is supported on a best-effort basis.\n\tSiege does NOT actively support bearer tokens; you need to manually set your current token in the OA2S_TOKEN environment variable.")
-					}
-				default:
-					return nil, nil, fmt.Errorf("The HTTP auth scheme %s (used in %s) is not currently supported.\n\tContact us to get it added!\n", scheme.Scheme, name)
-				}
-			case "mutualTLS":
-				cert, certExists := auth[name]["cert"]
-				key, keyExists := auth[name]["key"]
-				if !certExists || !keyExists {
-					return nil, nil, fmt.Errorf("Certificate and/or key not configured for %s scheme\n\tNeed `auth.%s.cert` and `auth.%s.key`\n", name, name, name)
-				}
+		if err = applyOAuth2Auth(name, flows, scopes, auth, filepath.Dir(c.Path("siege.config")), conf); err != nil {
+			return err
+		}
+	case "openIdConnect":
+		flows, err := getOpenIdConnectFlows(name, scheme.OpenIdConnectUrl, auth[name])
+		if err != nil {
+			return err
+		}
 
-				conf.SslUserCert = cert
-				conf.SslUserKey = key
-			case "oauth2":
-				return nil, nil, fmt.Errorf("Unsupported security scheme `oauth2` used in %s\n\tSiege doesn't currently support this authentication mechanism.\n", name)
-			case "openIdConnect":
-				return nil, nil, fmt.Errorf("Unsupported security scheme `openIdConnect` used in %s\n\tSiege doesn't currently support this authentication mechanism.\n", name)
-			default:
-				return nil, nil, fmt.Errorf("Unrecognized security scheme `%s` used in %s\n\tOpenAPI v3 doesn't support this authentication type, so we don't know how to proceed\n", scheme.Type, name)
-			}
+		if err = applyOAuth2Auth(name, flows, scopes, auth, filepath.Dir(c.Path("siege.config")), conf); err != nil {
+			return err
 		}
+	default:
+		return fmt.Errorf("Unrecognized security scheme `%s` used in %s\n\tOpenAPI v3 doesn't support this authentication type, so we don't know how to proceed\n", scheme.Type, name)
 	}
 
-	return urls, conf, nil
+	return nil
 }
 
-func getV3RequestNoPayload(c *cli.Context, method, rawPath string, baseUrl *url.URL, urls urlList, methodData *v3.Operation, pathConfig PathConfig) (urlList, error) {
+func getV3RequestNoPayload(c *cli.Context, gen *payloadGenerator, method, rawPath string, baseUrl *url.URL, urls urlList, methodData *v3.Operation, pathParams []*v3.Parameter, requirements []*base.SecurityRequirement, pathConfig PathConfig) (urlList, error) {
 	var err error
 
 	methodConfig, exists := pathConfig[method]
 	if !exists {
-		return nil, fmt.Errorf("`%s %s` not configured.\n\tNeed `paths.%s.%s.params.{name}`\n", strings.ToUpper(method), rawPath, rawPath, strings.ToLower(method))
+		return nil, fmt.Errorf("`%s %s` not configured.\n\tNeed `paths.%s.%s.params.{name}` (or `*` in place of the method)\n", strings.ToUpper(method), rawPath, rawPath, strings.ToLower(method))
 	}
 
 	pathBaseUrl := baseUrl
@@ -203,36 +211,53 @@ func getV3RequestNoPayload(c *cli.Context, method, rawPath string, baseUrl *url.
 		}
 	}
 
-	path, query, cookies, err := getV3PathParams(c, method, rawPath, methodData.Parameters, methodConfig)
+	auth, _ := c.Generic("auth").(AuthConfig)
+
+	security, err := chooseSecurityRequirement(method, rawPath, requirements, auth)
 	if err != nil {
 		return nil, err
 	}
 
-	pathUrl := pathBaseUrl.JoinPath(path)
+	params := mergeParameters(pathParams, methodData.Parameters, v3ParameterKey)
 
-	for _, cookie := range cookies {
-		cookie.Path = pathUrl.String()
-	}
+	err = gen.eachVariant(func(gen *payloadGenerator) error {
+		path, query, cookies, err := getV3PathParams(c, gen, method, rawPath, params, methodConfig)
+		if err != nil {
+			return err
+		}
+
+		pathUrl := pathBaseUrl.JoinPath(path)
 
-	pathUrl.RawQuery = query.Encode()
+		for _, cookie := range cookies {
+			cookie.Path = pathUrl.EscapedPath()
+		}
+
+		pathUrl.RawQuery = query.Encode()
+
+		urls = append(urls, urlData{
+			URL:       *pathUrl,
+			Method:    strings.ToUpper(method),
+			MediaType: "",
+			Payload:   "",
+			Cookies:   cookies,
+			Security:  security,
+		})
 
-	urls = append(urls, urlData{
-		URL:       *pathUrl,
-		Method:    strings.ToUpper(method),
-		MediaType: "",
-		Payload:   "",
-		Cookies:   cookies,
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return urls, nil
 }
 
-func getV3RequestWithPayload(c *cli.Context, method, rawPath string, baseUrl *url.URL, urls urlList, methodData *v3.Operation, pathConfig PathConfig) (urlList, error) {
+func getV3RequestWithPayload(c *cli.Context, gen *payloadGenerator, method, rawPath string, baseUrl *url.URL, urls urlList, methodData *v3.Operation, pathParams []*v3.Parameter, requirements []*base.SecurityRequirement, pathConfig PathConfig) (urlList, error) {
 	var err error
 
 	methodConfig, exists := pathConfig[method]
 	if !exists {
-		return nil, fmt.Errorf("`%s %s` not configured.\n\tNeed `paths.%s.%s.params.{name}` and/or `paths.%s.%s.payloads.{mediaType}`\n", strings.ToUpper(method), rawPath, rawPath, strings.ToLower(method), rawPath, strings.ToLower(method))
+		return nil, fmt.Errorf("`%s %s` not configured.\n\tNeed `paths.%s.%s.params.{name}` and/or `paths.%s.%s.payloads.{mediaType}` (or `*` in place of the method)\n", strings.ToUpper(method), rawPath, rawPath, strings.ToLower(method), rawPath, strings.ToLower(method))
 	}
 
 	pathBaseUrl := baseUrl
@@ -244,43 +269,70 @@ func getV3RequestWithPayload(c *cli.Context, method, rawPath string, baseUrl *ur
 		}
 	}
 
-	path, query, cookies, err := getV3PathParams(c, method, rawPath, methodData.Parameters, methodConfig)
+	auth, _ := c.Generic("auth").(AuthConfig)
+
+	security, err := chooseSecurityRequirement(method, rawPath, requirements, auth)
 	if err != nil {
 		return nil, err
 	}
 
-	pathUrl := pathBaseUrl.JoinPath(path)
+	params := mergeParameters(pathParams, methodData.Parameters, v3ParameterKey)
 
-	for _, cookie := range cookies {
-		cookie.Path = pathUrl.String()
-	}
+	err = gen.eachVariant(func(gen *payloadGenerator) error {
+		path, query, cookies, err := getV3PathParams(c, gen, method, rawPath, params, methodConfig)
+		if err != nil {
+			return err
+		}
 
-	pathUrl.RawQuery = query.Encode()
+		pathUrl := pathBaseUrl.JoinPath(path)
 
-	requests := make([]requestData, 0)
-	if methodData.RequestBody != nil {
-		requests, err = getV3PathPayloads(c, method, rawPath, *methodData.RequestBody, methodConfig)
-		if err != nil {
-			return nil, err
+		for _, cookie := range cookies {
+			cookie.Path = pathUrl.EscapedPath()
 		}
-	}
 
-	for _, request := range requests {
-		urls = append(urls, urlData{
-			URL:       *pathUrl,
-			Method:    strings.ToUpper(method),
-			MediaType: request.MediaType,
-			Payload:   request.Payload,
-			Cookies:   cookies,
-		})
+		pathUrl.RawQuery = query.Encode()
+
+		requests := make([]requestData, 0)
+		if methodData.RequestBody != nil {
+			requests, err = getV3PathPayloads(c, gen, method, rawPath, *methodData.RequestBody, methodConfig)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, request := range requests {
+			urls = append(urls, urlData{
+				URL:       *pathUrl,
+				Method:    strings.ToUpper(method),
+				MediaType: request.MediaType,
+				Payload:   request.Payload,
+				Cookies:   cookies,
+				Security:  security,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return urls, nil
 }
 
 func getV3BaseUrl(c *cli.Context, servers []*v3.Server) (*url.URL, error) {
+	baseUrl, _, err := getV3Server(c, servers)
+
+	return baseUrl, err
+}
+
+// getV3Server chooses a server, and resolves its URL, also returning the URL template and variable values for use
+// with `siege.variables`
+func getV3Server(c *cli.Context, servers []*v3.Server) (*url.URL, serverTemplate, error) {
 	for _, server := range servers {
 		rawUrl := server.URL
+		values := make(map[string]string)
+
 		for name, variable := range server.Variables {
 			var value string
 
@@ -294,26 +346,27 @@ func getV3BaseUrl(c *cli.Context, servers []*v3.Server) (*url.URL, error) {
 			}
 
 			if value == "" {
-				return nil, fmt.Errorf("Server variable not set and default is empty.\n\tCheck your configuration for `server.variables.%s`\n", name)
+				return nil, serverTemplate{}, fmt.Errorf("Server variable not set and default is empty.\n\tCheck your configuration for `server.variables.%s`\n", name)
 			}
 
 			rawUrl = strings.ReplaceAll(rawUrl, fmt.Sprintf("{%s}", name), value)
+			values[name] = value
 		}
 
 		baseUrl, err := url.Parse(rawUrl)
 		if err != nil {
-			return nil, err
+			return nil, serverTemplate{}, err
 		}
 
 		if len(servers) == 1 || c.String("server.description") == server.Description || c.Bool("server.useFirst") {
-			return baseUrl, nil
+			return baseUrl, serverTemplate{URL: server.URL, Variables: values}, nil
 		}
 	}
 
-	return nil, fmt.Errorf("Couldn't determine which server to use.\n\tCheck your configuration for `server.description` or `server.useFirst`.\n")
+	return nil, serverTemplate{}, fmt.Errorf("Couldn't determine which server to use.\n\tCheck your configuration for `server.description` or `server.useFirst`.\n")
 }
 
-func getV3PathParams(c *cli.Context, method, rawPath string, params []*v3.Parameter, config PathMethodConfig) (string, url.Values, []*http.Cookie, error) {
+func getV3PathParams(c *cli.Context, gen *payloadGenerator, method, rawPath string, params []*v3.Parameter, config PathMethodConfig) (string, url.Values, []*http.Cookie, error) {
 	path := rawPath
 	query := make(url.Values)
 	cookies := make([]*http.Cookie, 0)
@@ -325,38 +378,75 @@ func getV3PathParams(c *cli.Context, method, rawPath string, params []*v3.Parame
 
 		configValue, exists := config.Params[param.Name]
 		if !exists && param.Required {
-			switch {
-			case param.Example != nil:
-				paramValue = param.Example
-			case len(param.Examples) > 0:
-				for _, value := range param.Examples {
-					paramValue = value.Value
-					break
+			examples := exampleValues(param.Example, param.Examples)
+
+			// The parameter's own examples are more specific than its schema's enum, and may be named for expansion. The
+			// enum is only looked up without them, so an unused enum never adds variants of its own.
+			var enumValue interface{}
+			var hasEnum bool
+			if len(examples) < 1 {
+				var err error
+
+				enumValue, hasEnum, err = getV3ParamEnumValue(gen, param)
+				if err != nil {
+					return "", nil, nil, err
 				}
+			}
+
+			switch {
+			case len(examples) > 0:
+				paramValue = examples[gen.exampleIndex(len(examples))]
+				gen.nextExampleSet(len(examples))
+			case hasEnum:
+				paramValue = enumValue
 			case param.AllowEmptyValue:
 				paramValue = ""
+			case gen.auto && param.Schema != nil:
+				fakeValue, err := gen.createFakePayload(param.Schema)
+				if err != nil {
+					return "", nil, nil, fmt.Errorf("Couldn't generate a value for %s in %s %s\n\t%v\n\tNeed paths.%s.%s.params.%s\n", param.Name, strings.ToUpper(method), rawPath, err, rawPath, strings.ToLower(method), param.Name)
+				}
+
+				paramValue = fakeValue
 			default:
 				return "", nil, nil, fmt.Errorf("Unconfigured value for %s in %s %s, with no examples to draw from\n\tNeed paths.%s.%s.params.%s\n", param.Name, strings.ToUpper(method), rawPath, rawPath, strings.ToLower(method), param.Name)
 			}
 		}
 
 		if exists {
-			paramValue = configValue
+			var err error
+
+			paramValue, err = gen.paramConfigValue(configValue)
+			if err != nil {
+				return "", nil, nil, fmt.Errorf("Couldn't read the value for %s in %s %s\n\t%v\n\tCheck paths.%s.%s.params.%s\n", param.Name, strings.ToUpper(method), rawPath, err, rawPath, strings.ToLower(method), param.Name)
+			}
 		}
 
 		if exists || param.Required {
+			// Parameters with content are serialized by their media type instead of a style
+			for mediatype := range param.Content {
+				encoded, err := getPayloadFromType(mediatype, paramValue, nil)
+				if err != nil {
+					return "", nil, nil, err
+				}
+
+				paramValue = encoded
+				break
+			}
+
+			style, explode := getParamStyle(param.In, param.Style, param.Explode)
+
 			switch param.In {
 			case "path":
-				path = strings.ReplaceAll(path, fmt.Sprintf("{%s}", param.Name), interfaceToString(paramValue))
+				path = strings.ReplaceAll(path, fmt.Sprintf("{%s}", param.Name), serializePathParam(param.Name, paramValue, style, explode))
 			case "query":
-				query.Add(param.Name, interfaceToString(paramValue))
+				for key, values := range serializeQueryParam(param.Name, paramValue, style, explode) {
+					query[key] = append(query[key], values...)
+				}
 			case "header":
 				fmt.Printf("Per-request headers are unsupported by Siege; your tests may not work as expected\n\tSkipping %s for %s\n", param.Name, rawPath)
 			case "cookie":
-				cookies = append(cookies, &http.Cookie{
-					Name:  param.Name,
-					Value: interfaceToString(paramValue),
-				})
+				cookies = append(cookies, serializeCookieParam(param.Name, paramValue, explode)...)
 			}
 		}
 	}
@@ -364,49 +454,66 @@ func getV3PathParams(c *cli.Context, method, rawPath string, params []*v3.Parame
 	return path, query, cookies, nil
 }
 
-func getV3PathPayloads(c *cli.Context, method, rawPath string, body v3.RequestBody, config PathMethodConfig) ([]requestData, error) {
+func getV3PathPayloads(c *cli.Context, gen *payloadGenerator, method, rawPath string, body v3.RequestBody, config PathMethodConfig) ([]requestData, error) {
 	payloads := make([]requestData, 0)
 	var err error
 
 	// fmt.Printf("Processing path: %s %s - %v - %v\n", method, rawPath, body.Content, config.Payloads)
 
-	for mediatype, details := range body.Content {
-		payload, exists := config.Payloads[mediatype]
+	// Iterate media types in the same order every invocation
+	mediatypes := maps.Keys(body.Content)
+	sort.Strings(mediatypes)
 
-		if body.Required && !exists {
-			addedPayloads := false
-			if details.Example != nil {
-				payload, err = getPayloadFromType(mediatype, details.Example)
-				if err != nil {
-					return nil, err
-				}
+	// Each media type has its own examples, but they all count as one set when combining them with parameter examples
+	bodyExamples := 0
 
-				addedPayloads = true
+	for _, mediatype := range mediatypes {
+		details := body.Content[mediatype]
+		payload := ""
+
+		configured, exists := config.Payloads[mediatype]
+		if exists {
+			payload, err = getConfiguredPayload(c, mediatype, configured)
+			if err != nil {
+				return nil, fmt.Errorf("Couldn't use the configured payload for %s in %s %s\n\t%v\n\tCheck paths.%s.%s.payloads.%s\n", mediatype, strings.ToUpper(method), rawPath, err, rawPath, method, mediatype)
 			}
+		}
 
-			for _, example := range details.Examples {
-				var examplePayload string
+		// `--auto` fills in optional bodies too, since that's what most operations are for
+		if (body.Required || gen.auto) && !exists {
+			examples := exampleValues(details.Example, details.Examples)
 
-				examplePayload, err = getPayloadFromType(mediatype, example.Value)
-				if err != nil {
-					return nil, err
+			if len(examples) > 0 {
+				if len(examples) > bodyExamples {
+					bodyExamples = len(examples)
 				}
 
-				payloads = append(payloads, requestData{MediaType: mediatype, Payload: examplePayload})
+				var examplePayload interface{}
 
-				addedPayloads = true
-			}
+				examplePayload, err = gen.applyDataFields(examples[gen.exampleIndex(len(examples))], details.Schema, config.Fields)
+				if err != nil {
+					return nil, fmt.Errorf("Couldn't fill in the payload fields for %s %s\n\t%v\n\tCheck paths.%s.%s.fields\n", strings.ToUpper(method), rawPath, err, rawPath, method)
+				}
 
-			if !addedPayloads {
+				payload, err = getV3Payload(c, mediatype, examplePayload, details, config)
+				if err != nil {
+					return nil, err
+				}
+			} else {
 				var fakePayload interface{}
 
-				fakePayload, err = createFakePayload(details.Schema)
+				fakePayload, err = gen.createFakePayload(details.Schema)
 				if err != nil {
 					return nil, err
 				}
 
 				if fakePayload != nil {
-					payload, err = getPayloadFromType(mediatype, fakePayload)
+					fakePayload, err = gen.applyDataFields(fakePayload, details.Schema, config.Fields)
+					if err != nil {
+						return nil, fmt.Errorf("Couldn't fill in the payload fields for %s %s\n\t%v\n\tCheck paths.%s.%s.fields\n", strings.ToUpper(method), rawPath, err, rawPath, method)
+					}
+
+					payload, err = getV3Payload(c, mediatype, fakePayload, details, config)
 					if err != nil {
 						return nil, err
 					}
@@ -415,7 +522,7 @@ func getV3PathPayloads(c *cli.Context, method, rawPath string, body v3.RequestBo
 		}
 
 		if payload != "" {
-			payloads = append(payloads, requestData{MediaType: mediatype, Payload: payload})
+			payloads = append(payloads, requestData{MediaType: getPayloadMediaType(mediatype), Payload: payload})
 		}
 
 		if body.Required && len(payloads) < 1 {
@@ -423,6 +530,8 @@ func getV3PathPayloads(c *cli.Context, method, rawPath string, body v3.RequestBo
 		}
 	}
 
+	gen.nextExampleSet(bodyExamples)
+
 	if len(payloads) < 1 {
 		payloads = append(payloads, requestData{MediaType: "", Payload: "\"\""})
 	}
@@ -432,14 +541,126 @@ func getV3PathPayloads(c *cli.Context, method, rawPath string, body v3.RequestBo
 	return payloads, nil
 }
 
-func interfaceToString(in interface{}) string {
-	if out, isType := in.(string); isType {
-		return out
+// getV3Payload serializes a value as the given media type. XML bodies follow the schema's `xml` objects, and multipart
+// bodies are written to payload files, with file parts drawn from `paths.{path}.{method}.files.{name}`, or generated.
+func getV3Payload(c *cli.Context, mediatype string, data interface{}, details *v3.MediaType, config PathMethodConfig) (string, error) {
+	if isXMLMediaType(mediatype) {
+		payload, err := encodeXMLPayload(data, details.Schema)
+		if err != nil {
+			return "", err
+		}
+
+		return getURLFilePayload(c, mediatype, payload)
+	}
+
+	if !isMultipartMediaType(mediatype) {
+		payload, err := getPayloadFromType(mediatype, data, details.Encoding)
+		if err != nil {
+			return "", err
+		}
+
+		return getURLFilePayload(c, mediatype, payload)
+	}
+
+	// Don't serialize a body that's already serialized
+	if stringData, isType := data.(string); isType {
+		return stringData, nil
+	}
+
+	object, isObject := payloadToObject(data)
+	if !isObject {
+		return "", fmt.Errorf("Can't encode a %T as multipart form data; only objects are supported\n", data)
+	}
+
+	properties := make(map[string]*base.SchemaProxy)
+	if details.Schema != nil {
+		schema, err := details.Schema.BuildSchema()
+		if err != nil {
+			return "", err
+		}
+
+		properties = schema.Properties
+	}
+
+	names := maps.Keys(object)
+	sort.Strings(names)
+
+	parts := make([]multipartPart, 0, len(names))
+
+	for _, name := range names {
+		contentType := ""
+		if settings := details.Encoding[name]; settings != nil {
+			contentType = settings.ContentType
+		}
+
+		sampleFile, isFile := config.Files[name]
+		files := 1
+
+		if proxy, exists := properties[name]; exists {
+			if schema, err := proxy.BuildSchema(); err == nil {
+				if isBinarySchema(schema) {
+					isFile = true
+				} else if slices.Contains(schema.Type, "array") && schema.Items != nil && schema.Items.IsA() {
+					// Arrays of files are sent as repeated parts
+					if items, err := schema.Items.A.BuildSchema(); err == nil && isBinarySchema(items) {
+						list, _ := paramValueToList(object[name])
+						isFile, files = true, len(list)
+					}
+				}
+			}
+		}
+
+		if !isFile {
+			part, err := newMultipartTextPart(name, object[name], contentType)
+			if err != nil {
+				return "", err
+			}
+
+			parts = append(parts, part)
+			continue
+		}
+
+		for idx := 0; idx < files; idx++ {
+			part, err := newMultipartFilePart(c, name, contentType, sampleFile)
+			if err != nil {
+				return "", err
+			}
+
+			parts = append(parts, part)
+		}
+	}
+
+	return writeMultipartPayload(c, parts)
+}
+
+// isBinarySchema reports whether a schema describes raw file contents
+func isBinarySchema(schema *base.Schema) bool {
+	if schema.Format == "binary" {
+		return true
 	}
 
-	if out, isType := in.(fmt.Stringer); isType {
-		return out.String()
+	// OpenAPI 3.1 describes files by their media type instead
+	lowSchema := schema.GoLow()
+
+	return lowSchema != nil && !lowSchema.ContentMediaType.IsEmpty() && lowSchema.ContentEncoding.IsEmpty()
+}
+
+// getV3ParamEnumValue picks a value for a parameter whose schema declares a const or enum
+func getV3ParamEnumValue(gen *payloadGenerator, param *v3.Parameter) (interface{}, bool, error) {
+	if param.Schema == nil {
+		return nil, false, nil
 	}
 
-	return ""
+	schema, err := param.Schema.BuildSchema()
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, exists := gen.pickEnumValue(schema)
+
+	return value, exists, nil
+}
+
+func v3ParameterKey(param *v3.Parameter) string {
+	return fmt.Sprintf("%s:%s", param.In, param.Name)
 }
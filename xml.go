@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"golang.org/x/exp/maps"
+)
+
+// The root element name to use when neither the schema's `xml.name` nor its component name are available
+const xmlDefaultRoot = "root"
+
+// xmlHints is a schema's `xml` object, with libopenapi's prefix mix-up corrected
+type xmlHints struct {
+	Name      string
+	Namespace string
+	Prefix    string
+	Attribute bool
+	Wrapped   bool
+}
+
+// encodeXMLPayload serializes a payload as an XML document, following the `xml` objects of the schema and its
+// properties: names, namespaces, prefixes, attributes, and wrapped arrays. The root element is named for the schema's
+// component, as OpenAPI describes, unless its `xml.name` says otherwise.
+func encodeXMLPayload(data interface{}, proxy *base.SchemaProxy) (string, error) {
+	// Don't serialize a document that's already serialized
+	if stringData, isType := data.(string); isType && strings.HasPrefix(strings.TrimSpace(stringData), "<") {
+		return stringData, nil
+	}
+
+	name := xmlDefaultRoot
+
+	var schema *base.Schema
+	if proxy != nil {
+		var err error
+
+		schema, err = proxy.BuildSchema()
+		if err != nil {
+			return "", err
+		}
+
+		if proxy.GoLow().IsSchemaReference() {
+			name = path.Base(proxy.GoLow().GetSchemaReference())
+		}
+	}
+
+	builder := new(strings.Builder)
+	builder.WriteString(xml.Header)
+
+	if err := writeXMLRoot(builder, name, data, schema); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// writeXMLRoot writes the document element; a document needs a single root, so unlike array properties, root arrays
+// are always wrapped
+func writeXMLRoot(builder *strings.Builder, name string, data interface{}, schema *base.Schema) error {
+	list, isList := data.([]interface{})
+	if !isList {
+		return writeXMLElement(builder, name, data, schema)
+	}
+
+	hints := xmlHintsFor(schema)
+	if hints.Name != "" {
+		name = hints.Name
+	}
+
+	itemSchema := xmlItemSchema(schema)
+
+	writeXMLStart(builder, hints, name, nil)
+	for _, item := range list {
+		if err := writeXMLElement(builder, name, item, itemSchema); err != nil {
+			return err
+		}
+	}
+	writeXMLEnd(builder, hints, name)
+
+	return nil
+}
+
+// writeXMLElement writes a value as an element named for its property, or the name in its `xml` object. Arrays
+// become one element per item, inside a wrapper element when `xml.wrapped` is set.
+func writeXMLElement(builder *strings.Builder, name string, value interface{}, schema *base.Schema) error {
+	hints := xmlHintsFor(schema)
+
+	if list, isList := value.([]interface{}); isList {
+		itemSchema := xmlItemSchema(schema)
+
+		if !hints.Wrapped {
+			// An array's own name only applies to its wrapper
+			for _, item := range list {
+				if err := writeXMLElement(builder, name, item, itemSchema); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		if hints.Name != "" {
+			name = hints.Name
+		}
+
+		writeXMLStart(builder, hints, name, nil)
+		for _, item := range list {
+			if err := writeXMLElement(builder, name, item, itemSchema); err != nil {
+				return err
+			}
+		}
+		writeXMLEnd(builder, hints, name)
+
+		return nil
+	}
+
+	if hints.Name != "" {
+		name = hints.Name
+	}
+
+	object, isObject := value.(map[string]interface{})
+	if !isObject {
+		if value == nil {
+			writeXMLStart(builder, hints, name, nil)
+			writeXMLEnd(builder, hints, name)
+
+			return nil
+		}
+
+		if isComplexPayload(value) {
+			return fmt.Errorf("Can't encode a %T as XML\n", value)
+		}
+
+		writeXMLStart(builder, hints, name, nil)
+		builder.WriteString(escapeXML(renderParamValue(value)))
+		writeXMLEnd(builder, hints, name)
+
+		return nil
+	}
+
+	properties := xmlPropertySchemas(schema)
+
+	keys := maps.Keys(object)
+	sort.Strings(keys)
+
+	attributes := make([]string, 0)
+	children := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		propertyHints := xmlHintsFor(properties[key])
+		if !propertyHints.Attribute || isComplexPayload(object[key]) {
+			children = append(children, key)
+			continue
+		}
+
+		attributeName := key
+		if propertyHints.Name != "" {
+			attributeName = propertyHints.Name
+		}
+		if propertyHints.Prefix != "" {
+			attributeName = propertyHints.Prefix + ":" + attributeName
+		}
+
+		attributes = append(attributes, fmt.Sprintf(`%s="%s"`, attributeName, escapeXML(renderParamValue(object[key]))))
+	}
+
+	writeXMLStart(builder, hints, name, attributes)
+	for _, key := range children {
+		if err := writeXMLElement(builder, key, object[key], properties[key]); err != nil {
+			return err
+		}
+	}
+	writeXMLEnd(builder, hints, name)
+
+	return nil
+}
+
+func writeXMLStart(builder *strings.Builder, hints xmlHints, name string, attributes []string) {
+	builder.WriteString("<" + xmlQualifiedName(hints, name))
+
+	if hints.Namespace != "" {
+		if hints.Prefix != "" {
+			builder.WriteString(fmt.Sprintf(` xmlns:%s="%s"`, hints.Prefix, escapeXML(hints.Namespace)))
+		} else {
+			builder.WriteString(fmt.Sprintf(` xmlns="%s"`, escapeXML(hints.Namespace)))
+		}
+	}
+
+	for _, attribute := range attributes {
+		builder.WriteString(" " + attribute)
+	}
+
+	builder.WriteString(">")
+}
+
+func writeXMLEnd(builder *strings.Builder, hints xmlHints, name string) {
+	builder.WriteString("</" + xmlQualifiedName(hints, name) + ">")
+}
+
+func xmlQualifiedName(hints xmlHints, name string) string {
+	if hints.Prefix == "" {
+		return name
+	}
+
+	return hints.Prefix + ":" + name
+}
+
+// xmlHintsFor reads a schema's `xml` object. libopenapi v0.6 copies the namespace into the prefix, so the prefix is
+// read from the spec directly.
+func xmlHintsFor(schema *base.Schema) xmlHints {
+	if schema == nil || schema.XML == nil {
+		return xmlHints{}
+	}
+
+	hints := xmlHints{
+		Name:      schema.XML.Name,
+		Namespace: schema.XML.Namespace,
+		Attribute: schema.XML.Attribute,
+		Wrapped:   schema.XML.Wrapped,
+	}
+
+	if lowXML := schema.XML.GoLow(); lowXML != nil {
+		hints.Prefix = lowXML.Prefix.Value
+	}
+
+	return hints
+}
+
+// xmlItemSchema builds the item schema of an array schema, if it has one
+func xmlItemSchema(schema *base.Schema) *base.Schema {
+	if schema == nil || schema.Items == nil || !schema.Items.IsA() {
+		return nil
+	}
+
+	items, err := schema.Items.A.BuildSchema()
+	if err != nil {
+		return nil
+	}
+
+	return items
+}
+
+// xmlPropertySchemas collects the schemas for an object's properties, including those declared by its allOf, oneOf,
+// and anyOf subschemas, since generated payloads merge them together
+func xmlPropertySchemas(schema *base.Schema) map[string]*base.Schema {
+	properties := make(map[string]*base.Schema)
+	if schema == nil {
+		return properties
+	}
+
+	for name, proxy := range schema.Properties {
+		if property, err := proxy.BuildSchema(); err == nil {
+			properties[name] = property
+		}
+	}
+
+	for _, proxies := range [][]*base.SchemaProxy{schema.AllOf, schema.OneOf, schema.AnyOf} {
+		for _, proxy := range proxies {
+			subschema, err := proxy.BuildSchema()
+			if err != nil {
+				continue
+			}
+
+			for name, property := range xmlPropertySchemas(subschema) {
+				if _, exists := properties[name]; !exists {
+					properties[name] = property
+				}
+			}
+		}
+	}
+
+	return properties
+}
+
+func escapeXML(value string) string {
+	builder := new(strings.Builder)
+	_ = xml.EscapeText(builder, []byte(value))
+
+	return builder.String()
+}